@@ -8,15 +8,26 @@ import (
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
+	"sync"
 )
 
+// Api is a client of the Aternos website.
+// It's safe for concurrent use, although HTTP requests are sent one at a time.
 type Api struct {
 	Options *Options
 	client  *gotcha.Client
-	// ajax security token.
-	sec string
-	// ajax token.
-	token string
+	// clientMu serializes access to client, because it modifies its options while sending a request.
+	clientMu sync.Mutex
+	// ajax SEC and TOKEN credentials.
+	creds credentials
+	// Cache of tokens that were extracted from pages.
+	tokens tokenCache
+	// Rate limiters that must allow a request before it's sent.
+	limiters rateLimiters
+	// Logger with the server ID field set.
+	logger Logger
+	// Counters of sent requests.
+	stats requestStats
 }
 
 // New allocates a new Aternos API instance.
@@ -24,6 +35,9 @@ func New(options *Options) *Api {
 	jar, _ := cookiejar.New(&cookiejar.Options{})
 
 	adapter := tlsadapter.New(&tls.Config{ServerName: "aternos.org", InsecureSkipVerify: options.InsecureSkipVerify})
+	if options.Tracer != nil {
+		adapter.OnHandshake = options.Tracer.traceHandshake
+	}
 
 	client, _ := gotcha.NewClient(&gotcha.Options{
 		Adapter:   adapter,
@@ -55,8 +69,17 @@ func New(options *Options) *Api {
 	u, _ := url.Parse(client.Options.PrefixURL)
 	jar.SetCookies(u, options.Cookies)
 
+	var server string
+	for _, cookie := range options.Cookies {
+		if cookie.Name == "ATERNOS_SERVER" {
+			server = cookie.Value
+		}
+	}
+
 	return &Api{
-		Options: options,
-		client:  client,
+		Options:  options,
+		client:   client,
+		limiters: newRateLimiters(options),
+		logger:   withFields(options.Logger, "server", server),
 	}
 }
@@ -0,0 +1,36 @@
+package aternos_api
+
+import (
+	"github.com/sleeyax/gotcha"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestApi returns an Api that sends its requests to a local test server instead of Aternos.
+// Its ajax credentials are set to "sec" and "token".
+func newTestApi(t *testing.T, options *Options, handler http.HandlerFunc) *Api {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	if options.RateLimiter == nil {
+		options.RateLimiter = NewRateLimiter(nil)
+	}
+
+	api := New(options)
+	api.client.Options.Adapter = &gotcha.RequestAdapter{}
+	api.client.Options.PrefixURL = server.URL + "/"
+	api.creds.set("sec", "token")
+
+	return api
+}
+
+// testServerPage returns a minimal server page with given server info and ajax token.
+func testServerPage(info string, token string) string {
+	return `<html><head>
+<script type="text/javascript">(function() { window["AJAX_TOKEN"] = "` + token + `"; })();</script>
+<script>var lastStatus = ` + info + `;</script>
+</head><body></body></html>`
+}
@@ -0,0 +1,226 @@
+package aternos_api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Confirmation describes an attempt of an AutoConfirmer to confirm the server.
+type Confirmation struct {
+	// Time of the attempt.
+	Time time.Time
+
+	// Attempt number within the current turn in queue, starting at 1.
+	Attempt int
+
+	// Queue status at the time of the attempt.
+	Queue Queue
+
+	// Error of the attempt, nil if the server was confirmed.
+	Err error
+}
+
+// AutoConfirmer confirms the server each time it's its turn in queue (see QueuePending),
+// until the server leaves the Preparing status.
+//
+// Status changes are observed through websocket status messages, by registering it with Websocket.AddHandler,
+// and by polling over HTTP whenever no status has been received for PollInterval.
+// Without websocket connection it works purely by polling.
+type AutoConfirmer struct {
+	// Called after each confirmation attempt, whether it succeeded or not.
+	// It's called from the goroutine that executes Run.
+	OnConfirm func(confirmation Confirmation)
+
+	// Time to wait before a failed confirmation is retried.
+	RetryDelay time.Duration
+
+	// Maximum amount of failed attempts per turn, after which Run returns the last error.
+	// Zero means there's no limit.
+	MaxAttempts int
+
+	// Time to wait for a status update before polling the status over HTTP.
+	PollInterval time.Duration
+
+	// Time to wait for the server to leave the Offline status after Run is called,
+	// after which Run returns ServerNotStartedError. Zero means there's no limit.
+	StartTimeout time.Duration
+
+	// Time after a successful confirmation after which the server is confirmed again
+	// if Aternos still reports that it's waiting for confirmation.
+	ReconfirmAfter time.Duration
+
+	api *Api
+
+	mu      sync.Mutex
+	latest  *ServerInfo
+	version int
+	updates chan struct{}
+}
+
+// NewAutoConfirmer allocates a new AutoConfirmer with default settings.
+func NewAutoConfirmer(api *Api) *AutoConfirmer {
+	return &AutoConfirmer{
+		RetryDelay:     5 * time.Second,
+		MaxAttempts:    5,
+		PollInterval:   10 * time.Second,
+		StartTimeout:   2 * time.Minute,
+		ReconfirmAfter: time.Minute,
+		api:            api,
+		updates:        make(chan struct{}, 1),
+	}
+}
+
+// HandleMessage implements MessageHandler.
+// It observes status messages.
+func (c *AutoConfirmer) HandleMessage(msg WebsocketMessage) {
+	if msg.Type != "status" {
+		return
+	}
+
+	info, err := msg.serverInfo()
+	if err != nil {
+		return
+	}
+
+	c.observe(info)
+}
+
+// observe stores given server info as the most recent one and wakes up Run.
+func (c *AutoConfirmer) observe(info ServerInfo) {
+	c.mu.Lock()
+	c.latest = &info
+	c.version++
+	c.mu.Unlock()
+
+	select {
+	case c.updates <- struct{}{}:
+	default:
+	}
+}
+
+// current returns the most recently observed server info, if any, and its version.
+func (c *AutoConfirmer) current() (ServerInfo, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.latest == nil {
+		return ServerInfo{}, c.version, false
+	}
+	return *c.latest, c.version, true
+}
+
+// poll fetches the server status over HTTP.
+// The result is discarded if a status message was received in the meantime, since that one is more recent.
+func (c *AutoConfirmer) poll() error {
+	_, version, _ := c.current()
+
+	info, err := c.api.GetServerInfo()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == version {
+		c.latest = &info
+		c.version++
+	}
+
+	return nil
+}
+
+// Run confirms the server whenever required until it leaves the Preparing status, in which case nil is returned.
+// Call it right after Api.StartServer: an Offline status is ignored until the server has been seen preparing.
+//
+// It returns ctx.Err() when ctx is done, ServerNotStartedError when the server is still offline after StartTimeout,
+// or the last error when confirming failed MaxAttempts times in a row.
+func (c *AutoConfirmer) Run(ctx context.Context) error {
+	var startDeadline <-chan time.Time
+	if c.StartTimeout > 0 {
+		timer := time.NewTimer(c.StartTimeout)
+		defer timer.Stop()
+		startDeadline = timer.C
+	}
+
+	var (
+		seenPreparing bool
+		attempts      int
+		confirmedAt   time.Time
+		retryAt       time.Time
+		retry         <-chan time.Time
+	)
+
+	poll := time.After(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-startDeadline:
+			if !seenPreparing {
+				return ServerNotStartedError
+			}
+			startDeadline = nil
+			continue
+		case <-c.updates:
+		case <-retry:
+			retry = nil
+		case <-poll:
+			if err := c.poll(); err != nil {
+				c.api.logger.Warn("failed to poll server status while confirming", "error", err)
+				poll = time.After(c.PollInterval)
+				continue
+			}
+		}
+
+		info, _, ok := c.current()
+		if !ok {
+			continue
+		}
+
+		// Any observed status postpones polling.
+		poll = time.After(c.PollInterval)
+
+		if info.Status != Preparing {
+			if seenPreparing || info.Status != Offline {
+				return nil
+			}
+			continue
+		}
+		seenPreparing = true
+
+		if info.Queue.Status != QueuePending {
+			attempts = 0
+			confirmedAt = time.Time{}
+			continue
+		}
+
+		now := time.Now()
+		if now.Before(retryAt) || (!confirmedAt.IsZero() && now.Sub(confirmedAt) < c.ReconfirmAfter) {
+			continue
+		}
+
+		attempts++
+		err := c.api.confirm()
+
+		if c.OnConfirm != nil {
+			c.OnConfirm(Confirmation{Time: now, Attempt: attempts, Queue: info.Queue, Err: err})
+		}
+
+		if err == nil {
+			c.api.logger.Info("server confirmed", "attempt", attempts)
+			confirmedAt = now
+			retryAt = time.Time{}
+			attempts = 0
+			continue
+		}
+
+		c.api.logger.Warn("failed to confirm server", "attempt", attempts, "error", err)
+		if c.MaxAttempts > 0 && attempts >= c.MaxAttempts {
+			return err
+		}
+
+		retryAt = now.Add(c.RetryDelay)
+		retry = time.After(c.RetryDelay)
+	}
+}
@@ -0,0 +1,163 @@
+package aternos_api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestAutoConfirmer(api *Api) *AutoConfirmer {
+	c := NewAutoConfirmer(api)
+	c.RetryDelay = time.Millisecond
+	c.PollInterval = time.Millisecond
+	return c
+}
+
+func TestAutoConfirmer_Run(t *testing.T) {
+	var mu sync.Mutex
+	polls, confirms := 0, 0
+
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/server":
+			polls++
+			status := `{"status":0}`
+			switch {
+			case confirms >= 2:
+				status = `{"status":6}`
+			case polls > 2:
+				status = `{"status":10,"queue":{"position":0,"pending":"pending"}}`
+			case polls > 1:
+				status = `{"status":10,"queue":{"position":3,"pending":"waiting"}}`
+			}
+			w.Write([]byte(testServerPage(status, "token")))
+		case "/ajax/server/confirm":
+			confirms++
+			// The first attempt fails, so it must be retried.
+			if confirms == 1 {
+				w.Write([]byte(`{"success":false}`))
+				return
+			}
+			w.Write([]byte(`{"success":true}`))
+		}
+	})
+
+	var confirmations []Confirmation
+	c := newTestAutoConfirmer(api)
+	c.OnConfirm = func(confirmation Confirmation) {
+		confirmations = append(confirmations, confirmation)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(confirmations) != 2 {
+		t.Fatalf("expected 2 confirmation attempts, got %d", len(confirmations))
+	}
+	if confirmations[0].Err == nil || confirmations[0].Attempt != 1 {
+		t.Fatalf("expected first attempt to fail, got %+v", confirmations[0])
+	}
+	if confirmations[1].Err != nil || confirmations[1].Attempt != 2 {
+		t.Fatalf("expected second attempt to succeed, got %+v", confirmations[1])
+	}
+}
+
+func TestAutoConfirmer_Run_maxAttempts(t *testing.T) {
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/server":
+			w.Write([]byte(testServerPage(`{"status":10,"queue":{"pending":"pending"}}`, "token")))
+		case "/ajax/server/confirm":
+			w.Write([]byte(`{"success":false,"error":"eula"}`))
+		}
+	})
+
+	c := newTestAutoConfirmer(api)
+	c.MaxAttempts = 3
+
+	attempts := 0
+	c.OnConfirm = func(confirmation Confirmation) {
+		attempts++
+	}
+
+	err := c.Run(context.Background())
+	var ajaxErr *AjaxError
+	if !errors.As(err, &ajaxErr) || ajaxErr.Code != "eula" {
+		t.Fatalf("expected eula error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestAutoConfirmer_HandleMessage(t *testing.T) {
+	polled := make(chan struct{}, 1)
+	confirmed := make(chan struct{}, 1)
+
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/server":
+			w.Write([]byte(testServerPage(`{"status":10,"queue":{"pending":"waiting"}}`, "token")))
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+		case "/ajax/server/confirm":
+			confirmed <- struct{}{}
+			w.Write([]byte(`{"success":true}`))
+		}
+	})
+
+	c := NewAutoConfirmer(api)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Run(context.Background())
+	}()
+
+	// Wait for the initial poll, after which only status messages are observed.
+	<-polled
+	time.Sleep(10 * time.Millisecond)
+
+	c.HandleMessage(WebsocketMessage{Type: "status", MessageBytes: []byte(`{"status":10,"queue":{"pending":"pending"}}`)})
+
+	select {
+	case <-confirmed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server wasn't confirmed")
+	}
+
+	c.HandleMessage(WebsocketMessage{Type: "status", MessageBytes: []byte(`{"status":6}`)})
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after the server left the queue")
+	}
+}
+
+func TestAutoConfirmer_Run_startTimeout(t *testing.T) {
+	c := newTestAutoConfirmer(newFakeAternos(t, `{"status":0}`).newApi())
+	c.PollInterval = 10 * time.Millisecond
+	c.StartTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Run(ctx); err != ServerNotStartedError {
+		t.Fatalf("expected ServerNotStartedError, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// statuses are the known server statuses, exported as a state set.
+var statuses = []aternos.ServerStatus{
+	aternos.Offline,
+	aternos.Online,
+	aternos.Preparing,
+	aternos.Starting,
+	aternos.Stopping,
+	aternos.Restarting,
+	aternos.Saving,
+	aternos.Loading,
+	aternos.Crashed,
+}
+
+// exporter follows the server over websockets and serves its most recent state as Prometheus metrics.
+type exporter struct {
+	api *aternos.Api
+
+	mu sync.Mutex
+
+	// Current websocket connection, nil while disconnected.
+	wss *aternos.Websocket
+
+	// Most recently received server info, nil if none has been received yet.
+	info *aternos.ServerInfo
+
+	// Most recently received backup progress, nil if no backup has been made yet.
+	backup *aternos.BackupProgress
+}
+
+func newExporter(api *aternos.Api) *exporter {
+	return &exporter{api: api}
+}
+
+// run keeps following the server until ctx is done.
+func (e *exporter) run(ctx context.Context) {
+	cli.Reconnect(ctx, e.follow)
+}
+
+// follow observes messages of a single websocket connection until it drops or ctx is done.
+func (e *exporter) follow(ctx context.Context) error {
+	wss, err := e.api.ConnectWebSocket()
+	if err != nil {
+		return err
+	}
+
+	defer wss.Close()
+
+	e.mu.Lock()
+	e.wss = wss
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		e.wss = nil
+		e.mu.Unlock()
+	}()
+
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go wss.SendHearthBeats(heartbeatCtx)
+
+	// Whether the heap and tick streams have been started on this connection.
+	streaming := false
+
+	observe := func(info aternos.ServerInfo) {
+		e.mu.Lock()
+		e.info = &info
+		e.mu.Unlock()
+
+		switch {
+		case info.Status == aternos.Online && !streaming:
+			wss.StartHeapInfoStream()
+			wss.StartTickStream()
+			streaming = true
+		case info.Status != aternos.Online && streaming:
+			wss.StopHeapInfoStream()
+			wss.StopTickStream()
+			streaming = false
+		}
+	}
+
+	// Fetch the current status first, since the websocket server only sends changes.
+	if info, err := e.api.GetServerInfo(); err == nil {
+		observe(info)
+	} else {
+		log.Printf("Failed to get server info: %s\n", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-wss.Message:
+			if !ok {
+				return errors.New("connection closed")
+			}
+
+			switch msg.Type {
+			case "status":
+				var info aternos.ServerInfo
+				if err := json.Unmarshal(msg.MessageBytes, &info); err == nil {
+					observe(info)
+				}
+			case "backup_progress":
+				var backup aternos.BackupProgress
+				if err := json.Unmarshal(msg.MessageBytes, &backup); err == nil {
+					e.mu.Lock()
+					e.backup = &backup
+					e.mu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.write(w)
+}
+
+func (e *exporter) write(w io.Writer) {
+	e.mu.Lock()
+	wss, info, backup := e.wss, e.info, e.backup
+	e.mu.Unlock()
+
+	m := &metricsWriter{w: w}
+
+	m.metric("aternos_websocket_connected", "gauge", "Whether the exporter is connected to the websocket server.",
+		sample{value: boolValue(wss != nil && wss.IsConnected())})
+
+	if info != nil {
+		states := make([]sample, len(statuses))
+		for i, status := range statuses {
+			states[i] = sample{labels: label("status", status.String()), value: boolValue(info.Status == status)}
+		}
+		m.metric("aternos_server_status", "gauge", "Current server status, 1 for the active status.", states...)
+		m.metric("aternos_server_status_code", "gauge", "Numeric code of the current server status.", sample{value: float64(info.Status)})
+		m.metric("aternos_players_online", "gauge", "Amount of players online.", sample{value: float64(info.Players)})
+		m.metric("aternos_players_max", "gauge", "Maximum amount of players.", sample{value: float64(info.MaxPlayers)})
+		m.metric("aternos_queue_position", "gauge", "Position in queue, 0 if not in queue.", sample{value: float64(info.Queue.Position)})
+		m.metric("aternos_queue_size", "gauge", "Amount of servers in queue, 0 if not in queue.", sample{value: float64(info.Queue.Count)})
+	}
+
+	if wss != nil {
+		m.runtime(info, wss.Metrics())
+	}
+
+	if backup != nil {
+		m.metric("aternos_backup_progress_percent", "gauge", "Progress of the most recent backup.", sample{value: float64(backup.Progress)})
+	}
+
+	stats := e.api.RequestStats()
+	endpoints := make([]string, 0, len(stats))
+	for endpoint := range stats {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	requests := make([]sample, len(endpoints))
+	errs := make([]sample, len(endpoints))
+	retries := make([]sample, len(endpoints))
+	for i, endpoint := range endpoints {
+		l := label("endpoint", endpoint)
+		requests[i] = sample{labels: l, value: float64(stats[endpoint].Requests)}
+		errs[i] = sample{labels: l, value: float64(stats[endpoint].Errors)}
+		retries[i] = sample{labels: l, value: float64(stats[endpoint].Retries)}
+	}
+	m.metric("aternos_client_requests_total", "counter", "HTTP requests sent to Aternos, including retries.", requests...)
+	m.metric("aternos_client_request_errors_total", "counter", "HTTP requests that failed.", errs...)
+	m.metric("aternos_client_request_retries_total", "counter", "HTTP requests that were retries of a failed request.", retries...)
+}
+
+// sample is a single value of a metric.
+type sample struct {
+	// Formatted labels, e.g. `status="online"`.
+	labels string
+	value  float64
+}
+
+// metricsWriter writes metrics in the Prometheus text exposition format.
+type metricsWriter struct {
+	w io.Writer
+}
+
+// metric writes a metric with given samples.
+// Nothing is written if there are no samples.
+func (m *metricsWriter) metric(name, kind, help string, samples ...sample) {
+	if len(samples) == 0 {
+		return
+	}
+
+	fmt.Fprintf(m.w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(m.w, "# TYPE %s %s\n", name, kind)
+
+	for _, s := range samples {
+		value := strconv.FormatFloat(s.value, 'g', -1, 64)
+		if s.labels == "" {
+			fmt.Fprintf(m.w, "%s %s\n", name, value)
+		} else {
+			fmt.Fprintf(m.w, "%s{%s} %s\n", name, s.labels, value)
+		}
+	}
+}
+
+// runtime writes the heap and tick metrics of given snapshot.
+// They're omitted unless the server is online, since the most recent samples of the window would be stale otherwise.
+func (m *metricsWriter) runtime(info *aternos.ServerInfo, snapshot aternos.MetricsSnapshot) {
+	if info == nil || info.Status != aternos.Online {
+		return
+	}
+
+	if snapshot.Heap.Usage.Count > 0 {
+		m.metric("aternos_heap_bytes", "gauge", "Used heap memory in bytes.", sample{value: snapshot.Heap.Usage.Last})
+	}
+	if snapshot.Heap.Available > 0 {
+		m.metric("aternos_heap_available_bytes", "gauge", "Memory available to the server in bytes.", sample{value: float64(snapshot.Heap.Available)})
+	}
+	if snapshot.Tick.TickTime.Count > 0 {
+		m.metric("aternos_tick_time_milliseconds", "gauge", "Average time it takes to process a tick.", sample{value: snapshot.Tick.TickTime.Last})
+		m.metric("aternos_tps", "gauge", "Ticks per second.", sample{value: snapshot.Tick.TPS.Last})
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label formats a label with given name and value.
+func label(name, value string) string {
+	return name + `="` + labelEscaper.Replace(value) + `"`
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	aternos "github.com/sleeyax/aternos-api"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExporter_ServeHTTP(t *testing.T) {
+	e := newExporter(aternos.New(&aternos.Options{}))
+	e.info = &aternos.ServerInfo{Status: aternos.Preparing, Players: 0, MaxPlayers: 20, Queue: aternos.Queue{Position: 4, Count: 12}}
+	e.backup = &aternos.BackupProgress{Progress: 75}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		"# TYPE aternos_server_status gauge",
+		`aternos_server_status{status="preparing"} 1`,
+		`aternos_server_status{status="online"} 0`,
+		"aternos_server_status_code 10",
+		"aternos_players_max 20",
+		"aternos_queue_position 4",
+		"aternos_queue_size 12",
+		"aternos_backup_progress_percent 75",
+		"aternos_websocket_connected 0",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, body)
+		}
+	}
+
+	// Metrics without samples are omitted entirely.
+	if strings.Contains(body, "aternos_client_requests_total") || strings.Contains(body, "aternos_heap_bytes") {
+		t.Errorf("unexpected metrics without samples in:\n%s", body)
+	}
+}
+
+func TestMetricsWriter_runtime(t *testing.T) {
+	var snapshot aternos.MetricsSnapshot
+	snapshot.Heap.Usage = aternos.SampleStats{Count: 1, Last: 1024}
+	snapshot.Tick.TickTime = aternos.SampleStats{Count: 1, Last: 40}
+	snapshot.Tick.TPS = aternos.SampleStats{Count: 1, Last: 20}
+
+	online := &strings.Builder{}
+	(&metricsWriter{w: online}).runtime(&aternos.ServerInfo{Status: aternos.Online}, snapshot)
+	for _, line := range []string{"aternos_heap_bytes 1024", "aternos_tick_time_milliseconds 40", "aternos_tps 20"} {
+		if !strings.Contains(online.String(), line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, online)
+		}
+	}
+
+	// The samples of the window are stale once the server has left Online.
+	for _, info := range []*aternos.ServerInfo{nil, {Status: aternos.Stopping}, {Status: aternos.Offline}} {
+		offline := &strings.Builder{}
+		(&metricsWriter{w: offline}).runtime(info, snapshot)
+		if offline.Len() != 0 {
+			t.Errorf("unexpected metrics while not online:\n%s", offline)
+		}
+	}
+
+	// An empty window yields no metrics either.
+	empty := &strings.Builder{}
+	(&metricsWriter{w: empty}).runtime(&aternos.ServerInfo{Status: aternos.Online}, aternos.MetricsSnapshot{})
+	if empty.Len() != 0 {
+		t.Errorf("unexpected metrics without samples:\n%s", empty)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	if l := label("endpoint", "a\"b\\c\nd"); l != `endpoint="a\"b\\c\nd"` {
+		t.Fatalf("unexpected label %s", l)
+	}
+}
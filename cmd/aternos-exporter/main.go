@@ -0,0 +1,49 @@
+// Command aternos-exporter exposes the status of an Aternos server as Prometheus metrics.
+//
+// It follows the server over the websocket connection, subscribes to the heap and tick streams
+// while the server is online and serves the most recent values on /metrics.
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	// Parse CLI flags.
+	listen := flag.String("listen", ":9150", "address to serve metrics on")
+	creds := cli.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	api, err := creds.NewApi()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	e := newExporter(api)
+	go e.run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+
+	srv := &http.Server{Addr: *listen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	log.Printf("Serving metrics on %s/metrics\n", *listen)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// openapi describes the HTTP API.
+//
+//go:embed openapi.yaml
+var openapi []byte
+
+// server is the part of aternos.Api that the gateway controls the server with.
+type server interface {
+	GetServerInfo() (aternos.ServerInfo, error)
+	StartServer() error
+	StopServer() error
+}
+
+// console executes commands in the server console.
+type console interface {
+	SendCommand(command string) error
+}
+
+// startCall is a StartServer call that's in progress.
+// Concurrent start requests wait for the same call, rather than starting the server again.
+type startCall struct {
+	done chan struct{}
+	err  error
+}
+
+// gateway exposes the server over a local HTTP API.
+// It keeps a single websocket connection to Aternos, through which commands are executed and the status is followed.
+type gateway struct {
+	api     server
+	apiKeys []string
+
+	// connect connects to the websocket server.
+	connect func() (*aternos.Websocket, error)
+
+	// confirm keeps confirming the server until it leaves the queue.
+	// Message handlers that it needs are registered with given websocket, if it's not nil.
+	confirm func(ctx context.Context, wss *aternos.Websocket) error
+
+	// Context of run, in which the server is confirmed in the background.
+	ctx context.Context
+
+	mu sync.Mutex
+
+	// Current websocket connection and its console, nil while disconnected.
+	wss     *aternos.Websocket
+	console console
+
+	// Most recently received server info, nil if none has been received on the current connection.
+	info *aternos.ServerInfo
+
+	start      *startCall
+	confirming bool
+}
+
+func newGateway(api *aternos.Api, apiKeys []string) *gateway {
+	return &gateway{
+		api:     api,
+		apiKeys: apiKeys,
+		connect: api.ConnectWebSocket,
+		confirm: func(ctx context.Context, wss *aternos.Websocket) error {
+			confirmer := aternos.NewAutoConfirmer(api)
+			if wss != nil {
+				defer wss.AddHandler(confirmer)()
+			}
+			return confirmer.Run(ctx)
+		},
+		ctx: context.Background(),
+	}
+}
+
+// run keeps the websocket connection alive until ctx is done.
+func (g *gateway) run(ctx context.Context) {
+	g.mu.Lock()
+	g.ctx = ctx
+	g.mu.Unlock()
+
+	cli.Reconnect(ctx, g.follow)
+}
+
+// follow observes messages of a single websocket connection until it drops or ctx is done.
+func (g *gateway) follow(ctx context.Context) error {
+	wss, err := g.connect()
+	if err != nil {
+		return err
+	}
+
+	defer wss.Close()
+
+	g.mu.Lock()
+	g.wss = wss
+	g.console = wss
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		g.wss = nil
+		g.console = nil
+		g.info = nil
+		g.mu.Unlock()
+	}()
+
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go wss.SendHearthBeats(heartbeatCtx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-wss.Message:
+			if !ok {
+				return errors.New("connection closed")
+			}
+
+			if msg.Type == "status" {
+				var info aternos.ServerInfo
+				if err := json.Unmarshal(msg.MessageBytes, &info); err == nil {
+					g.mu.Lock()
+					g.info = &info
+					g.mu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+// serverInfo returns the most recent server info.
+// It's received over the websocket connection, or fetched over HTTP if none has been received.
+func (g *gateway) serverInfo() (aternos.ServerInfo, error) {
+	g.mu.Lock()
+	info := g.info
+	g.mu.Unlock()
+
+	if info != nil {
+		return *info, nil
+	}
+
+	return g.api.GetServerInfo()
+}
+
+// startServer starts the server, or waits for the start that's in progress.
+// Once started, the server is confirmed in the background when it's its turn in queue.
+func (g *gateway) startServer() error {
+	g.mu.Lock()
+	if call := g.start; call != nil {
+		g.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+	call := &startCall{done: make(chan struct{})}
+	g.start = call
+	g.mu.Unlock()
+
+	call.err = g.api.StartServer()
+
+	g.mu.Lock()
+	g.start = nil
+	if call.err == nil || errors.Is(call.err, aternos.ServerInQueueError) {
+		g.startConfirming()
+	}
+	g.mu.Unlock()
+
+	close(call.done)
+
+	return call.err
+}
+
+// startConfirming confirms the server in the background, unless that's already happening.
+// The caller must hold g.mu.
+func (g *gateway) startConfirming() {
+	if g.confirming {
+		return
+	}
+	g.confirming = true
+
+	ctx, wss := g.ctx, g.wss
+	go func() {
+		if err := g.confirm(ctx, wss); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to confirm server: %s\n", err)
+		}
+
+		g.mu.Lock()
+		g.confirming = false
+		g.mu.Unlock()
+	}()
+}
+
+// handler returns the HTTP handler that serves the API.
+func (g *gateway) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/status", g.authorize(http.MethodGet, g.handleStatus))
+	mux.Handle("/players", g.authorize(http.MethodGet, g.handlePlayers))
+	mux.Handle("/start", g.authorize(http.MethodPost, g.handleStart))
+	mux.Handle("/stop", g.authorize(http.MethodPost, g.handleStop))
+	mux.Handle("/command", g.authorize(http.MethodPost, g.handleCommand))
+	mux.HandleFunc("/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(openapi)
+	})
+	return mux
+}
+
+// authorize only passes requests with given method and a valid API key to handler.
+// The key is either sent as bearer token in the Authorization header, or in the X-API-Key header.
+func (g *gateway) authorize(method string, handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		if !g.validKey(key) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid API key"))
+			return
+		}
+
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+
+		handler(w, r)
+	})
+}
+
+func (g *gateway) validKey(key string) bool {
+	valid := false
+	for _, k := range g.apiKeys {
+		// Compare all keys in constant time, so the response time doesn't reveal a valid key.
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid && key != ""
+}
+
+func (g *gateway) handleStatus(w http.ResponseWriter, r *http.Request) {
+	info, err := g.serverInfo()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (g *gateway) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	info, err := g.serverInfo()
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	players := info.PlayerList
+	if players == nil || info.Status != aternos.Online {
+		players = []string{}
+	}
+
+	writeJSON(w, http.StatusOK, playersResponse{Count: len(players), Max: info.MaxPlayers, Players: players})
+}
+
+func (g *gateway) handleStart(w http.ResponseWriter, r *http.Request) {
+	if err := g.startServer(); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, actionResponse{Status: "starting"})
+}
+
+func (g *gateway) handleStop(w http.ResponseWriter, r *http.Request) {
+	if err := g.api.StopServer(); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, actionResponse{Status: "stopping"})
+}
+
+func (g *gateway) handleCommand(w http.ResponseWriter, r *http.Request) {
+	var req commandRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	command := strings.TrimSpace(req.Command)
+	if command == "" || strings.ContainsAny(command, "\r\n") {
+		writeError(w, http.StatusBadRequest, errors.New("command must be a single non-empty line"))
+		return
+	}
+
+	g.mu.Lock()
+	c, info := g.console, g.info
+	g.mu.Unlock()
+
+	if c == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("not connected to the websocket server"))
+		return
+	}
+	if info != nil && info.Status != aternos.Online {
+		writeError(w, http.StatusConflict, fmt.Errorf("server is %s", info.Status))
+		return
+	}
+
+	if err := c.SendCommand(command); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type actionResponse struct {
+	Status string `json:"status"`
+}
+
+type playersResponse struct {
+	Count   int      `json:"count"`
+	Max     int      `json:"max"`
+	Players []string `json:"players"`
+}
+
+type commandRequest struct {
+	Command string `json:"command"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// errorStatus returns the response status code for an error of the Aternos API.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, aternos.ServerAlreadyStartedError),
+		errors.Is(err, aternos.ServerAlreadyStoppedError),
+		errors.Is(err, aternos.ServerInQueueError):
+		return http.StatusConflict
+	case errors.Is(err, aternos.EulaNotAcceptedError),
+		errors.Is(err, aternos.WrongVersionError),
+		errors.Is(err, aternos.StorageLimitError):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, aternos.FileServerUnavailableError):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
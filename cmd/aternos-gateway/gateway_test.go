@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	aternos "github.com/sleeyax/aternos-api"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeServer struct {
+	info    aternos.ServerInfo
+	starts  int32
+	release chan struct{}
+}
+
+func (s *fakeServer) GetServerInfo() (aternos.ServerInfo, error) {
+	return s.info, nil
+}
+
+func (s *fakeServer) StartServer() error {
+	atomic.AddInt32(&s.starts, 1)
+	<-s.release
+	return nil
+}
+
+func (s *fakeServer) StopServer() error {
+	return aternos.ServerAlreadyStoppedError
+}
+
+type fakeConsole struct {
+	commands []string
+}
+
+func (c *fakeConsole) SendCommand(command string) error {
+	c.commands = append(c.commands, command)
+	return nil
+}
+
+func newTestGateway(server *fakeServer) *gateway {
+	return &gateway{
+		api:     server,
+		apiKeys: []string{"key"},
+		confirm: func(ctx context.Context, wss *aternos.Websocket) error { return nil },
+		ctx:     context.Background(),
+	}
+}
+
+func request(g *gateway, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer key")
+	rec := httptest.NewRecorder()
+	g.handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGateway_auth(t *testing.T) {
+	g := newTestGateway(&fakeServer{})
+
+	for _, header := range []string{"", "Bearer wrong"} {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		g.handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected %q to be unauthorized, got %d", header, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set("X-API-Key", "key")
+	rec := httptest.NewRecorder()
+	g.handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected X-API-Key to be accepted, got %d", rec.Code)
+	}
+
+	if rec = request(g, http.MethodGet, "/start", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET /start not to be allowed, got %d", rec.Code)
+	}
+
+	// The description is public.
+	rec = httptest.NewRecorder()
+	g.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "openapi: ") {
+		t.Errorf("expected OpenAPI description, got %d", rec.Code)
+	}
+}
+
+func TestGateway_statusAndPlayers(t *testing.T) {
+	g := newTestGateway(&fakeServer{info: aternos.ServerInfo{Status: aternos.Offline}})
+	g.info = &aternos.ServerInfo{Status: aternos.Online, MaxPlayers: 20, PlayerList: []string{"Steve"}}
+
+	rec := request(g, http.MethodGet, "/status", "")
+	var info struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil || info.Status != "online" {
+		t.Fatalf("expected most recent status online, got %s", rec.Body)
+	}
+
+	rec = request(g, http.MethodGet, "/players", "")
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"count":1,"max":20,"players":["Steve"]}` {
+		t.Fatalf("unexpected players %s", body)
+	}
+}
+
+func TestGateway_startCoalesces(t *testing.T) {
+	server := &fakeServer{release: make(chan struct{})}
+	g := newTestGateway(server)
+
+	var confirms int32
+	g.confirm = func(ctx context.Context, wss *aternos.Websocket) error {
+		atomic.AddInt32(&confirms, 1)
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- request(g, http.MethodPost, "/start", "").Code
+		}()
+	}
+
+	// Wait until the first request started the server, and give the others time to join it.
+	for atomic.LoadInt32(&server.starts) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(server.release)
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		if code != http.StatusAccepted {
+			t.Errorf("expected all requests to be accepted, got %d", code)
+		}
+	}
+	if starts := atomic.LoadInt32(&server.starts); starts != 1 {
+		t.Fatalf("expected server to be started once, got %d", starts)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&confirms); n != 1 {
+		t.Fatalf("expected server to be confirmed once, got %d", n)
+	}
+}
+
+func TestGateway_stop(t *testing.T) {
+	g := newTestGateway(&fakeServer{})
+
+	rec := request(g, http.MethodPost, "/stop", "")
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "already stopped") {
+		t.Fatalf("expected conflict, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGateway_command(t *testing.T) {
+	g := newTestGateway(&fakeServer{})
+
+	if rec := request(g, http.MethodPost, "/command", `{"command":"say hi"}`); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected unavailable without websocket connection, got %d", rec.Code)
+	}
+
+	c := &fakeConsole{}
+	g.console = c
+	g.info = &aternos.ServerInfo{Status: aternos.Offline}
+
+	if rec := request(g, http.MethodPost, "/command", `{"command":"say hi"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("expected conflict while offline, got %d", rec.Code)
+	}
+
+	g.info.Status = aternos.Online
+
+	for _, body := range []string{`{"command":""}`, `{"command":"a\nb"}`, `nope`} {
+		if rec := request(g, http.MethodPost, "/command", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected %s to be rejected, got %d", body, rec.Code)
+		}
+	}
+
+	if rec := request(g, http.MethodPost, "/command", `{"command":"say hi"}`); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected command to be sent, got %d", rec.Code)
+	}
+	if len(c.commands) != 1 || c.commands[0] != "say hi" {
+		t.Fatalf("unexpected commands %q", c.commands)
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	if keys := splitKeys(" a, ,b,"); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("unexpected keys %q", keys)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{aternos.ServerAlreadyStartedError, http.StatusConflict},
+		{aternos.ServerInQueueError, http.StatusConflict},
+		{aternos.EulaNotAcceptedError, http.StatusUnprocessableEntity},
+		{aternos.StorageLimitError, http.StatusUnprocessableEntity},
+		{aternos.FileServerUnavailableError, http.StatusServiceUnavailable},
+		{errors.New("other"), http.StatusBadGateway},
+	}
+
+	for _, test := range tests {
+		if status := errorStatus(test.err); status != test.status {
+			t.Errorf("%v: expected status %d, got %d", test.err, test.status, status)
+		}
+	}
+}
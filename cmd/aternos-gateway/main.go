@@ -0,0 +1,65 @@
+// Command aternos-gateway exposes an Aternos server over a local HTTP API,
+// so it can be controlled by services that aren't written in Go.
+//
+// It keeps a single connection to Aternos and serves GET /status, GET /players, POST /start, POST /stop and POST /command.
+// Requests must be authenticated with an API key. The API is described in /openapi.yaml.
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	// Parse CLI flags.
+	listen := flag.String("listen", "127.0.0.1:9160", "address to serve the API on")
+	apiKeys := flag.String("api-keys", os.Getenv("ATERNOS_GATEWAY_API_KEYS"), "comma separated API keys that clients authenticate with (ATERNOS_GATEWAY_API_KEYS)")
+	creds := cli.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	keys := splitKeys(*apiKeys)
+	if len(keys) == 0 {
+		log.Fatal("missing API keys: specify them with -api-keys or the ATERNOS_GATEWAY_API_KEYS environment variable")
+	}
+
+	api, err := creds.NewApi()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g := newGateway(api, keys)
+	go g.run(ctx)
+
+	srv := &http.Server{Addr: *listen, Handler: g.handler()}
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	log.Printf("Serving API on %s\n", *listen)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// splitKeys splits a comma separated list of API keys.
+func splitKeys(s string) []string {
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
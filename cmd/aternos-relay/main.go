@@ -0,0 +1,51 @@
+// Command aternos-relay shares the websocket connection to an Aternos server with many local subscribers.
+//
+// It serves the status, console, heap, tick, queue and backup events as server-sent events on /events
+// and over websockets on /ws. Select streams with the streams query parameter, e.g. /events?streams=status,console.
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"github.com/sleeyax/aternos-api/relay"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	// Parse CLI flags.
+	listen := flag.String("listen", "127.0.0.1:9170", "address to serve events on")
+	replay := flag.Int("replay", 100, "amount of console lines that are replayed to new subscribers")
+	creds := cli.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	api, err := creds.NewApi()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := relay.New(api)
+	r.ReplayLines = *replay
+	r.ReconnectDelay = cli.ReconnectDelay
+	r.OnError = cli.LogReconnect
+	go r.Run(ctx)
+
+	srv := &http.Server{Addr: *listen, Handler: r.Handler()}
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	log.Printf("Serving events on %s/events and %s/ws\n", *listen, *listen)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"testing"
+)
+
+func TestParseCookies(t *testing.T) {
+	for _, input := range []string{
+		"# exported cookies\nATERNOS_SESSION=abc\n\nATERNOS_SERVER = def\n",
+		`[{"name":"ATERNOS_SESSION","value":"abc"},{"name":"ATERNOS_SERVER","value":"def"}]`,
+	} {
+		cookies, err := parseCookies([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cookies) != 2 || cookies[0] != (exportedCookie{"ATERNOS_SESSION", "abc"}) || cookies[1] != (exportedCookie{"ATERNOS_SERVER", "def"}) {
+			t.Fatalf("unexpected cookies %+v", cookies)
+		}
+	}
+
+	if _, err := parseCookies([]byte("ATERNOS_SESSION")); exitCode(err) != exitUsage {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, exitOK},
+		{flag.ErrHelp, exitOK},
+		{usageErrorf("bad"), exitUsage},
+		{fmt.Errorf("request failed: %w", aternos.UnauthenticatedError), exitAuth},
+		{aternos.ServerAlreadyStartedError, exitConflict},
+		{errNotInQueue, exitConflict},
+		{context.DeadlineExceeded, exitTimeout},
+		{fmt.Errorf("other"), exitError},
+	}
+
+	for _, test := range tests {
+		if code := exitCode(test.err); code != test.code {
+			t.Errorf("%v: expected exit code %d, got %d", test.err, test.code, code)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"golang.org/x/term"
+	"os"
+	"time"
+)
+
+// consoleCommand opens a full-screen console to follow the server and execute commands.
+func consoleCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("console", flag.ContinueOnError)
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return usageErrorf("the console requires an interactive terminal")
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	wss, err := api.ConnectWebSocket()
+	if err != nil {
+		return err
+	}
+	defer wss.Close()
+
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go wss.SendHearthBeats(heartbeatCtx)
+
+	tracker := aternos.NewQueueTracker()
+	wss.AddHandler(tracker)
+
+	model := &consoleModel{}
+
+	// Start the streams that correspond to the server status.
+	streams := func(status aternos.ServerStatus) {
+		switch status {
+		case aternos.Starting, aternos.Loading, aternos.Stopping, aternos.Saving:
+			wss.StartConsoleLogStream()
+		case aternos.Online:
+			wss.StartConsoleLogStream()
+			wss.StartHeapInfoStream()
+			wss.StartTickStream()
+		}
+	}
+
+	if info, err := api.GetServerInfo(); err == nil {
+		model.info = &info
+		tracker.Observe(info)
+		streams(info.Status)
+	} else {
+		model.notice = fmt.Sprintf("Failed to get server info: %s", err)
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	fmt.Print(ansiAltScreen)
+	defer fmt.Print(ansiMainScreen + ansiShowCursor)
+
+	input := make(chan []byte)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(input)
+				return
+			}
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			input <- data
+		}
+	}()
+
+	// The screen is redrawn periodically, which also picks up terminal resizes.
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	width, height := 80, 24
+	draw := func() {
+		if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			width, height = w, h
+		}
+		model.metrics = wss.Metrics()
+		model.queue = tracker.Estimate()
+		fmt.Print(model.render(width, height))
+	}
+
+	fmt.Print(ansiClear)
+	draw()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case msg, ok := <-wss.Message:
+			if !ok {
+				return errors.New("websocket connection closed")
+			}
+
+			switch msg.Type {
+			case "line":
+				if msg.Stream == "console" {
+					model.addLine(msg.Data.Content)
+				}
+			case "status":
+				var info aternos.ServerInfo
+				if err := json.Unmarshal(msg.MessageBytes, &info); err == nil {
+					if model.info == nil || model.info.Status != info.Status {
+						streams(info.Status)
+					}
+					model.info = &info
+				}
+			}
+		case data, ok := <-input:
+			if !ok {
+				return nil
+			}
+
+			for _, k := range parseKeys(data) {
+				command, quit := model.press(k, height-3)
+				if quit {
+					return nil
+				}
+				if command == "" {
+					continue
+				}
+
+				model.notice = ""
+				if err := wss.SendCommand(command); err != nil {
+					model.notice = fmt.Sprintf("Failed to execute command: %s", err)
+				}
+			}
+		}
+
+		draw()
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// Maximum amount of console lines to keep.
+	consoleMaxLines = 1000
+
+	// Maximum amount of commands to remember.
+	consoleMaxHistory = 100
+)
+
+// ANSI escape sequences.
+const (
+	ansiClear       = "\x1b[2J"
+	ansiHome        = "\x1b[H"
+	ansiClearLine   = "\x1b[K"
+	ansiReverse     = "\x1b[7m"
+	ansiDim         = "\x1b[2m"
+	ansiReset       = "\x1b[0m"
+	ansiAltScreen   = "\x1b[?1049h"
+	ansiMainScreen  = "\x1b[?1049l"
+	ansiShowCursor  = "\x1b[?25h"
+	ansiHideCursor  = "\x1b[?25l"
+	ansiMoveCursor  = "\x1b[%d;%dH"
+	ansiEscape      = 0x1b
+	ansiBackspace   = 0x7f
+	ansiCtrlC       = 0x03
+	ansiCtrlD       = 0x04
+	ansiCtrlH       = 0x08
+	ansiCtrlU       = 0x15
+	ansiCarriageRet = '\r'
+	ansiNewline     = '\n'
+)
+
+// ansiSequence matches escape sequences in console lines, which would mess up the layout.
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// keyType is the type of a key press.
+type keyType int
+
+const (
+	keyRune keyType = iota
+	keyEnter
+	keyBackspace
+	keyClearLine
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyQuit
+)
+
+// key is a key press.
+type key struct {
+	typ keyType
+
+	// Typed character, only set for keyRune.
+	r rune
+}
+
+// parseKeys parses the bytes that were read from a terminal in raw mode.
+func parseKeys(data []byte) []key {
+	var keys []key
+
+	s := string(data)
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "\x1b[A"):
+			keys, s = append(keys, key{typ: keyUp}), s[3:]
+		case strings.HasPrefix(s, "\x1b[B"):
+			keys, s = append(keys, key{typ: keyDown}), s[3:]
+		case strings.HasPrefix(s, "\x1b[5~"):
+			keys, s = append(keys, key{typ: keyPageUp}), s[4:]
+		case strings.HasPrefix(s, "\x1b[6~"):
+			keys, s = append(keys, key{typ: keyPageDown}), s[4:]
+		case s[0] == ansiEscape:
+			// Skip unsupported escape sequences, such as left and right arrows.
+			if m := ansiSequence.FindStringIndex(s); m != nil && m[0] == 0 {
+				s = s[m[1]:]
+			} else {
+				s = s[1:]
+			}
+		case s[0] == ansiCarriageRet || s[0] == ansiNewline:
+			keys, s = append(keys, key{typ: keyEnter}), s[1:]
+		case s[0] == ansiBackspace || s[0] == ansiCtrlH:
+			keys, s = append(keys, key{typ: keyBackspace}), s[1:]
+		case s[0] == ansiCtrlU:
+			keys, s = append(keys, key{typ: keyClearLine}), s[1:]
+		case s[0] == ansiCtrlC || s[0] == ansiCtrlD:
+			keys, s = append(keys, key{typ: keyQuit}), s[1:]
+		case s[0] < 0x20:
+			s = s[1:]
+		default:
+			r := []rune(s)[0]
+			keys, s = append(keys, key{typ: keyRune, r: r}), s[len(string(r)):]
+		}
+	}
+
+	return keys
+}
+
+// consoleModel is the state of the console TUI.
+type consoleModel struct {
+	// Most recent server info, nil if unknown.
+	info *aternos.ServerInfo
+
+	metrics aternos.MetricsSnapshot
+	queue   aternos.QueueEstimate
+
+	// Console lines, oldest first.
+	lines []string
+
+	// Amount of lines scrolled up from the bottom.
+	scroll int
+
+	// Command that's being typed.
+	input []rune
+
+	// Previously executed commands, oldest first.
+	history []string
+
+	// Index in history while browsing it, len(history) if not browsing.
+	historyIndex int
+
+	// Message shown below the console, e.g. an error.
+	notice string
+}
+
+// addLine appends a console line.
+func (m *consoleModel) addLine(line string) {
+	line = ansiSequence.ReplaceAllString(strings.TrimRight(line, "\r\n"), "")
+	line = strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return ' '
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, line)
+
+	m.lines = append(m.lines, line)
+	if len(m.lines) > consoleMaxLines {
+		m.lines = m.lines[len(m.lines)-consoleMaxLines:]
+	}
+
+	// Keep the same lines in view while scrolled up.
+	if m.scroll > 0 {
+		m.scroll++
+	}
+}
+
+// press handles a key press.
+// It returns a command to execute, if any, and whether to quit.
+func (m *consoleModel) press(k key, pageSize int) (command string, quit bool) {
+	switch k.typ {
+	case keyRune:
+		m.input = append(m.input, k.r)
+	case keyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case keyClearLine:
+		m.input = nil
+	case keyEnter:
+		command = strings.TrimSpace(string(m.input))
+		m.input = nil
+		if command != "" {
+			m.history = append(m.history, command)
+			if len(m.history) > consoleMaxHistory {
+				m.history = m.history[1:]
+			}
+		}
+		m.historyIndex = len(m.history)
+		m.scroll = 0
+	case keyUp:
+		if m.historyIndex > 0 {
+			m.historyIndex--
+			m.input = []rune(m.history[m.historyIndex])
+		}
+	case keyDown:
+		if m.historyIndex < len(m.history)-1 {
+			m.historyIndex++
+			m.input = []rune(m.history[m.historyIndex])
+		} else {
+			m.historyIndex = len(m.history)
+			m.input = nil
+		}
+	case keyPageUp:
+		m.scroll += pageSize
+		if max := len(m.lines) - pageSize; m.scroll > max {
+			m.scroll = max
+		}
+		if m.scroll < 0 {
+			m.scroll = 0
+		}
+	case keyPageDown:
+		m.scroll -= pageSize
+		if m.scroll < 0 {
+			m.scroll = 0
+		}
+	case keyQuit:
+		quit = true
+	}
+
+	return command, quit
+}
+
+// statusBar returns the text of the status bar.
+func (m *consoleModel) statusBar() string {
+	if m.info == nil {
+		return "Connecting..."
+	}
+
+	parts := []string{m.info.Name, m.info.Status.String()}
+	parts = append(parts, fmt.Sprintf("players %d/%d", m.info.Players, m.info.MaxPlayers))
+
+	if heap := m.metrics.Heap; heap.Usage.Count > 0 {
+		if heap.Percentage.Count > 0 {
+			parts = append(parts, fmt.Sprintf("heap %.0f%%", heap.Percentage.Last))
+		} else {
+			parts = append(parts, fmt.Sprintf("heap %d MB", int64(heap.Usage.Last)/1024/1024))
+		}
+	}
+
+	if tick := m.metrics.Tick; tick.TickTime.Count > 0 {
+		parts = append(parts, fmt.Sprintf("tick %.1f ms (%.1f TPS)", tick.TickTime.Last, tick.TPS.Last))
+	}
+
+	if m.info.Status == aternos.Preparing {
+		queue := fmt.Sprintf("queue %d/%d", m.queue.Position, m.queue.Count)
+		if m.queue.ETA > 0 {
+			queue += fmt.Sprintf(" ETA %s", m.queue.ETA.Round(time.Second))
+		}
+		parts = append(parts, queue)
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// render returns the escape sequences that draw the whole screen with given size.
+func (m *consoleModel) render(width, height int) string {
+	if width < 10 || height < 4 {
+		return ansiHome + ansiClear + "Terminal too small"
+	}
+
+	var b strings.Builder
+	b.WriteString(ansiHideCursor + ansiHome)
+
+	// Status bar.
+	b.WriteString(ansiReverse + pad(m.statusBar(), width) + ansiReset + "\r\n")
+
+	// Console lines.
+	pageSize := height - 3
+	end := len(m.lines) - m.scroll
+	start := end - pageSize
+	if start < 0 {
+		start = 0
+	}
+	for i := 0; i < pageSize; i++ {
+		line := ""
+		if start+i < end {
+			line = m.lines[start+i]
+		}
+		b.WriteString(truncate(line, width) + ansiClearLine + "\r\n")
+	}
+
+	// Hint or notice.
+	hint := "Enter: execute command | Up/Down: history | PgUp/PgDn: scroll | Ctrl+C: quit"
+	if m.scroll > 0 {
+		hint = fmt.Sprintf("Scrolled up %d lines | %s", m.scroll, hint)
+	}
+	if m.notice != "" {
+		hint = m.notice
+	}
+	b.WriteString(ansiDim + truncate(hint, width) + ansiReset + ansiClearLine + "\r\n")
+
+	// Input line, scrolled horizontally so the cursor stays visible.
+	input := m.input
+	if len(input) > width-3 {
+		input = input[len(input)-(width-3):]
+	}
+	b.WriteString("> " + string(input) + ansiClearLine)
+	b.WriteString(fmt.Sprintf(ansiMoveCursor, height, len(input)+3) + ansiShowCursor)
+
+	return b.String()
+}
+
+// truncate shortens s to at most width characters.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return s
+}
+
+// pad truncates s or pads it with spaces to exactly width characters.
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-len([]rune(s)))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	aternos "github.com/sleeyax/aternos-api"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("sé\x7f\x1b[A\x1b[D\x1b[6~\r\x03"))
+
+	want := []key{
+		{typ: keyRune, r: 's'},
+		{typ: keyRune, r: 'é'},
+		{typ: keyBackspace},
+		{typ: keyUp},
+		{typ: keyPageDown},
+		{typ: keyEnter},
+		{typ: keyQuit},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("expected %+v, got %+v", want, keys)
+	}
+}
+
+func TestConsoleModel_press(t *testing.T) {
+	m := &consoleModel{}
+
+	for _, k := range parseKeys([]byte("say hi")) {
+		m.press(k, 10)
+	}
+	if command, _ := m.press(key{typ: keyEnter}, 10); command != "say hi" {
+		t.Fatalf("expected command, got %q", command)
+	}
+	for _, k := range parseKeys([]byte("list")) {
+		m.press(k, 10)
+	}
+	m.press(key{typ: keyEnter}, 10)
+
+	// Browse the history.
+	m.press(key{typ: keyUp}, 10)
+	m.press(key{typ: keyUp}, 10)
+	if string(m.input) != "say hi" {
+		t.Fatalf("expected previous command, got %q", string(m.input))
+	}
+	m.press(key{typ: keyDown}, 10)
+	m.press(key{typ: keyDown}, 10)
+	if len(m.input) != 0 {
+		t.Fatalf("expected empty input, got %q", string(m.input))
+	}
+
+	if _, quit := m.press(key{typ: keyQuit}, 10); !quit {
+		t.Fatal("expected to quit")
+	}
+}
+
+func TestConsoleModel_render(t *testing.T) {
+	m := &consoleModel{info: &aternos.ServerInfo{Name: "test", Status: aternos.Preparing, MaxPlayers: 20}}
+	m.queue = aternos.QueueEstimate{Position: 3, Count: 12}
+	for i := 0; i < 10; i++ {
+		m.addLine("\x1b[32mline\x1b[0m " + strings.Repeat("x", i*10))
+	}
+	m.input = []rune("say hello")
+
+	screen := m.render(40, 6)
+
+	for _, s := range []string{
+		"test | preparing | players 0/20 | queue",
+		"line xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx\x1b[K", // truncated to the width
+		"> say hello",
+	} {
+		if !strings.Contains(screen, s) {
+			t.Errorf("expected screen to contain %q:\n%q", s, screen)
+		}
+	}
+	if strings.Contains(screen, "\x1b[32m") {
+		t.Error("expected escape sequences of console lines to be stripped")
+	}
+
+	// Only the last lines fit on the screen.
+	if strings.Count(screen, "line") != 3 {
+		t.Errorf("expected 3 console lines:\n%q", screen)
+	}
+
+	m.press(key{typ: keyPageUp}, 3)
+	m.press(key{typ: keyPageUp}, 3)
+	if !strings.Contains(m.render(40, 6), "line xxxxxxxxxx\x1b[K") {
+		t.Error("expected to scroll up")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// exportedCookie is a cookie as it's exported and imported.
+type exportedCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cookiesCommand exports or imports the authentication cookies.
+func cookiesCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("usage: aternos cookies export|import [file]")
+	}
+
+	switch args[0] {
+	case "export":
+		return exportCookies(args[1:])
+	case "import":
+		return importCookies(args[1:])
+	default:
+		return usageErrorf("unknown cookies command %q, expected export or import", args[0])
+	}
+}
+
+// exportCookies prints the current authentication cookies.
+func exportCookies(args []string) error {
+	fs := flag.NewFlagSet("cookies export", flag.ContinueOnError)
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	var cookies []exportedCookie
+	for _, cookie := range api.GetCookies() {
+		cookies = append(cookies, exportedCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	output(cookies, func(w io.Writer) {
+		for _, cookie := range cookies {
+			fmt.Fprintf(w, "%s=%s\n", cookie.Name, cookie.Value)
+		}
+	})
+
+	return nil
+}
+
+// importCookies reads cookies from a file or stdin and saves them to the config file.
+// Both the human readable and the JSON output of 'cookies export' are accepted.
+func importCookies(args []string) error {
+	fs := flag.NewFlagSet("cookies import", flag.ContinueOnError)
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	cookies, err := parseCookies(data)
+	if err != nil {
+		return err
+	}
+
+	c, err := cli.LoadConfig(*global.Config)
+	if err != nil {
+		return err
+	}
+
+	imported := cli.Config{}
+	for _, cookie := range cookies {
+		switch cookie.Name {
+		case "ATERNOS_SESSION":
+			imported.Session = cookie.Value
+		case "ATERNOS_SERVER":
+			imported.Server = cookie.Value
+		case "ATERNOS_LANGUAGE":
+			imported.Language = cookie.Value
+		}
+	}
+	if imported.Session == "" && imported.Server == "" {
+		return usageErrorf("no ATERNOS_SESSION or ATERNOS_SERVER cookie found")
+	}
+
+	if err = c.Merge(imported).Save(*global.Config); err != nil {
+		return err
+	}
+
+	output(map[string]string{"config": *global.Config}, func(w io.Writer) {
+		fmt.Fprintf(w, "Cookies saved to %s.\n", *global.Config)
+	})
+
+	return nil
+}
+
+// parseCookies parses either a JSON array of cookies or lines of name=value pairs.
+func parseCookies(data []byte) ([]exportedCookie, error) {
+	var cookies []exportedCookie
+
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &cookies); err != nil {
+			return nil, usageErrorf("invalid cookies: %s", err)
+		}
+		return cookies, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, usageErrorf("invalid cookie %q, expected name=value", line)
+		}
+		cookies = append(cookies, exportedCookie{Name: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+	}
+
+	return cookies, scanner.Err()
+}
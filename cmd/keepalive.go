@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	aternos "github.com/sleeyax/aternos-api"
+	"log"
+	"strings"
+	"time"
+)
+
+// keepaliveCommand restarts the server whenever it stops.
+func keepaliveCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keepalive", flag.ContinueOnError)
+	windows := fs.String("windows", "", "comma separated daily time windows in which the server is kept running, e.g. 08:00-23:00,22:00-02:00 (default always)")
+	delay := fs.Duration("delay", time.Minute, "time to wait before restarting the server")
+	maxRestarts := fs.Int("max-restarts", 5, "maximum amount of restarts within the restart period, 0 for no limit")
+	period := fs.Duration("period", 6*time.Hour, "restart period")
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	supervisor := aternos.NewSupervisor(api)
+	supervisor.RestartDelay = *delay
+	supervisor.MaxRestarts = *maxRestarts
+	supervisor.RestartPeriod = *period
+
+	if *windows != "" {
+		for _, s := range strings.Split(*windows, ",") {
+			window, err := parseTimeWindow(s)
+			if err != nil {
+				return err
+			}
+			supervisor.Windows = append(supervisor.Windows, window)
+		}
+	}
+
+	supervisor.OnEvent = func(event aternos.SupervisorEvent) {
+		switch event.Type {
+		case aternos.SupervisorStopped:
+			log.Printf("Server is %s, restarting in %s\n", event.Status, *delay)
+		case aternos.SupervisorRestarting:
+			log.Printf("Restarting server (%d restart(s) in the last %s)\n", event.Restarts, *period)
+		case aternos.SupervisorRestarted:
+			log.Println("Server restarted.")
+		case aternos.SupervisorFailed:
+			log.Printf("Failed to restart server: %s\n", event.Err)
+		case aternos.SupervisorSkipped:
+			log.Printf("Not restarting server: %s\n", event.Reason)
+		}
+	}
+
+	log.Println("Keeping server alive, press CTRL + C to quit.")
+
+	supervisor.Run(ctx)
+
+	return nil
+}
+
+// parseTimeWindow parses a time window such as "08:00-23:00".
+func parseTimeWindow(s string) (aternos.TimeWindow, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return aternos.TimeWindow{}, usageErrorf("invalid time window %q, expected e.g. 08:00-23:00", s)
+	}
+
+	var bounds [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse("15:04", part)
+		if err != nil {
+			return aternos.TimeWindow{}, usageErrorf("invalid time window %q: %s", s, err)
+		}
+		bounds[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+
+	return aternos.TimeWindow{Start: bounds[0], End: bounds[1]}, nil
+}
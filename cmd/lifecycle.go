@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"io"
+	"time"
+)
+
+// errNotInQueue is returned when the server must be confirmed while it isn't waiting in queue.
+var errNotInQueue = errors.New("server isn't waiting in queue")
+
+// startCommand starts the server.
+func startCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	wait := fs.Bool("wait", false, "confirm the server and wait until it's online")
+	timeout := fs.Duration("timeout", time.Hour, "maximum amount of time to wait")
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	err = api.StartServer()
+	if err != nil && !(*wait && (errors.Is(err, aternos.ServerAlreadyStartedError) || errors.Is(err, aternos.ServerInQueueError))) {
+		return err
+	}
+
+	if !*wait {
+		output(map[string]string{"result": "starting"}, func(w io.Writer) {
+			fmt.Fprintln(w, "Server is starting, run 'aternos confirm' when it's your turn in queue.")
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	// Confirm the server in the background while waiting.
+	confirmer := aternos.NewAutoConfirmer(api)
+	confirmer.OnConfirm = func(confirmation aternos.Confirmation) {
+		if confirmation.Err != nil {
+			progress("Failed to confirm server: %s", confirmation.Err)
+			return
+		}
+		progress("Confirmed server.")
+	}
+	go confirmer.Run(ctx)
+
+	return waitForStatus(ctx, api, aternos.Online)
+}
+
+// stopCommand stops the server.
+func stopCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	wait := fs.Bool("wait", false, "wait until the server is offline")
+	timeout := fs.Duration("timeout", 10*time.Minute, "maximum amount of time to wait")
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	err = api.StopServer()
+	if err != nil && !(*wait && errors.Is(err, aternos.ServerAlreadyStoppedError)) {
+		return err
+	}
+
+	if !*wait {
+		output(map[string]string{"result": "stopping"}, func(w io.Writer) {
+			fmt.Fprintln(w, "Server is stopping.")
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	return waitForStatus(ctx, api, aternos.Offline)
+}
+
+// confirmCommand confirms the server until it has left the queue.
+func confirmCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("confirm", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", time.Hour, "maximum amount of time to wait in queue")
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	info, err := api.GetServerInfo()
+	if err != nil {
+		return err
+	}
+	if info.Status != aternos.Preparing {
+		return fmt.Errorf("%w (status: %s)", errNotInQueue, info.Status)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	confirmations := 0
+	confirmer := aternos.NewAutoConfirmer(api)
+	confirmer.OnConfirm = func(confirmation aternos.Confirmation) {
+		if confirmation.Err != nil {
+			progress("Failed to confirm server: %s", confirmation.Err)
+			return
+		}
+		confirmations++
+		progress("Confirmed server.")
+	}
+
+	progress("Waiting in queue (position %d/%d, %s)...", info.Queue.Position, info.Queue.Count, info.Queue.Time)
+
+	if err = confirmer.Run(ctx); err != nil {
+		return err
+	}
+
+	output(map[string]int{"confirmations": confirmations}, func(w io.Writer) {
+		fmt.Fprintln(w, "Server has left the queue.")
+	})
+
+	return nil
+}
+
+// waitForStatus waits until the server reaches given status, reporting each change.
+func waitForStatus(ctx context.Context, api *aternos.Api, want aternos.ServerStatus) error {
+	for change := range api.WatchStatus(ctx) {
+		if change.To == aternos.Preparing {
+			progress("Server is %s (queue position %d/%d, %s)", change.To, change.Info.Queue.Position, change.Info.Queue.Count, change.Info.Queue.Time)
+		} else {
+			progress("Server is %s", change.To)
+		}
+
+		if change.To == want {
+			output(change.Info, func(w io.Writer) {
+				if want == aternos.Online {
+					fmt.Fprintf(w, "Server is online at %s:%d.\n", change.Info.Address, change.Info.Port)
+				} else {
+					fmt.Fprintf(w, "Server is %s.\n", want)
+				}
+			})
+			return nil
+		}
+
+		if want == aternos.Online && change.From != change.To && change.To.IsStopped() {
+			return fmt.Errorf("server is %s", change.To)
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// logsCommand prints the console log.
+// With -json, each line is printed as a JSON object on a line of its own (JSON Lines).
+func logsCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	follow := fs.Bool("follow", false, "keep printing new lines until interrupted")
+	idle := fs.Duration("idle", 3*time.Second, "without -follow, stop once no line has been received for this long")
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	wss, err := api.ConnectWebSocket()
+	if err != nil {
+		return err
+	}
+	defer wss.Close()
+
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go wss.SendHearthBeats(heartbeatCtx)
+
+	if err = wss.StartConsoleLogStream(); err != nil {
+		return err
+	}
+
+	// The websocket server sends the recent lines right after the stream has started.
+	var timeout <-chan time.Time
+	if !*follow {
+		timeout = time.After(*idle)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timeout:
+			return nil
+		case msg, ok := <-wss.Message:
+			if !ok {
+				return errors.New("websocket connection closed")
+			}
+
+			if msg.Stream != "console" || msg.Type != "line" {
+				continue
+			}
+
+			line := strings.TrimRight(msg.Data.Content, "\n")
+			if jsonOutput {
+				printJSONLine(os.Stdout, map[string]string{"line": line})
+			} else {
+				fmt.Println(line)
+			}
+
+			if !*follow {
+				timeout = time.After(*idle)
+			}
+		}
+	}
+}
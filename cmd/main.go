@@ -1,174 +1,145 @@
+// Command aternos controls an Aternos server from the command line.
+//
+// Credentials are read from flags, environment variables or a config file, see 'aternos help'.
 package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	aternos "github.com/sleeyax/aternos-api"
-	"log"
-	"net/http"
-	"net/url"
+	"github.com/sleeyax/aternos-api/internal/cli"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 )
 
-func main() {
-	// Parse CLI flags.
-	session := flag.String("session", "", "ATERNOS_SESSION")
-	lang := flag.String("lang", "en", "ATERNOS_LANGUAGE")
-	server := flag.String("server", "", "ATERNOS_SERVER")
-	proxy := flag.String("proxy", "", "optional proxy to connect to")
-	flag.Parse()
-
-	// Check if all required flags are specified.
-	if *session == "" || *server == "" {
-		fmt.Println("Missing cookie values.")
-		fmt.Println()
-		fmt.Println("Usage:")
-		fmt.Println()
-		flag.PrintDefaults()
-		fmt.Println()
-		fmt.Println("Example: aternos-api -lang en -proxy http://127.0.0.1:8888 -server a6liGFpEBjF1LvIS -session a6HBs6rMiHJvDHxQQfCDKQQmutFSWgIJhQVgzaPOSvr6TCXtdZMPKIlGjqubur80l2AYp9r9seRMilWHznPv05U9mVPCiPm8we2e")
-		os.Exit(0)
+// Exit codes.
+const (
+	exitOK = 0
+
+	// Unspecified error.
+	exitError = 1
+
+	// Invalid usage, such as an unknown subcommand or missing credentials.
+	exitUsage = 2
+
+	// The credentials were rejected or the request was blocked.
+	exitAuth = 3
+
+	// The server already is in the requested state, e.g. it's already started.
+	exitConflict = 4
+
+	// Waiting for the server timed out.
+	exitTimeout = 5
+)
+
+// command is a subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"status", "", "show the server status", statusCommand},
+		{"start", "[-wait]", "start the server, confirming it when it's your turn in queue", startCommand},
+		{"stop", "[-wait]", "stop the server", stopCommand},
+		{"confirm", "", "confirm the server while it's waiting in queue", confirmCommand},
+		{"logs", "[-follow]", "print the console log", logsCommand},
+		{"console", "", "open an interactive console", consoleCommand},
+		{"players", "", "list the players that are online", playersCommand},
+		{"cookies", "export|import [file]", "export or import the authentication cookies", cookiesCommand},
+		{"keepalive", "", "restart the server whenever it stops", keepaliveCommand},
+		{"schedule", "-start <cron> -stop <cron>", "start and stop the server on a schedule", scheduleCommand},
 	}
+}
 
-	// Parse proxy (if specified).
-	var p *url.URL
-	if *proxy != "" {
-		p, _ = url.Parse(*proxy)
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: aternos <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %-28s %s\n", c.name, c.args, c.summary)
 	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'aternos <command> -h' for the flags of a command.")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintf(os.Stderr, "Credentials are read from flags, the %s and %s environment variables, or the config file %s.\n", cli.EnvSession, cli.EnvServer, cli.DefaultConfigPath())
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Exit codes: 0 success, 1 error, 2 invalid usage, 3 authentication failed, 4 server already in requested state, 5 timeout.")
+}
 
-	// Create a new api instance, with all required authentication cookies set.
-	api := aternos.New(&aternos.Options{
-		Cookies: []*http.Cookie{
-			{
-				Name:  "ATERNOS_LANGUAGE",
-				Value: *lang,
-			},
-			{
-				Name:  "ATERNOS_SESSION",
-				Value: *session,
-			},
-			{
-				Name:  "ATERNOS_SERVER",
-				Value: *server,
-			},
-		},
-		Proxy:              p,
-		InsecureSkipVerify: true,
-	})
-
-	// Connect to the Aternos websocket server.
-	wss, err := api.ConnectWebSocket()
-	if err != nil {
-		log.Fatal(err)
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
 	}
 
-	defer wss.Close() // closes the connection when the main function ends
-
-	ctx, cancel := context.WithCancel(context.Background())
-	interruptSignal := make(chan os.Signal, 1)
-	signal.Notify(interruptSignal, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
-
-	log.Println("Started websocket connection.")
-
-	for {
-		select {
-		case msg := <-wss.Message:
-			switch msg.Type {
-			case "ready":
-				// Start the server over HTTP.
-				if err = api.StartServer(); err != nil {
-					log.Println(err)
-					return
-				}
-
-				// Run a goroutine in the background that sends a bunch of keep-alive requests at intervals.
-				go wss.SendHearthBeats(ctx)
-			case "status":
-				// Current server status, containing a bunch of other useful info such as IP address/Dyn IP to connect to, amount of active players, detected problems etc.
-				var serverInfo aternos.ServerInfo
-				json.Unmarshal(msg.MessageBytes, &serverInfo)
-
-				log.Printf("Server status: %s\n", serverInfo.StatusLabel)
-
-				switch serverInfo.Status {
-				case aternos.Starting:
-					// Start streaming console logs.
-					wss.StartConsoleLogStream()
-				case aternos.Online:
-					// Stop streaming console logs
-					// and start streaming heap & tick info.
-					wss.StopConsoleLogStream()
-					wss.StartHeapInfoStream()
-					wss.StartTickStream()
-				case aternos.Stopping:
-					// Stop heap & tick stream..
-					wss.StopHeapInfoStream()
-					wss.StopTickStream()
-				case aternos.Offline:
-					cancel() // stops sending heartbeats.
-					return   // closes the connection.
-				}
-			case "queue_reduced":
-				// Waiting queue.
-				// Your server will start once it's your turn in this queue.
-				var queue aternos.QueueReduction
-				json.Unmarshal(msg.MessageBytes, &queue)
-				log.Printf("Reduced queue %d: %d people in queue (max time %d)\n", queue.Number, queue.Total, queue.MaxTime)
-			case "line":
-				// Console stream.
-				// Usually contains verbose output that is useful for logging/debugging purposes.
-				log.Printf("%s: %s\n", strings.Title(msg.Stream), thisOrThat(msg.Data.Content, msg.Type))
-			case "heap":
-				// Current memory usage.
-				var heap aternos.Heap
-				json.Unmarshal(msg.Data.ContentBytes, &heap)
-				log.Printf("Heap usage in bytes: %d\n", heap.Usage)
-			case "tick":
-				var tick aternos.Tick
-				json.Unmarshal(msg.Data.ContentBytes, &tick)
-				log.Printf("Tick time: %f\n", tick.AverageTickTime)
-			case "started":
-				// Started sending a specific stream (console, heap or tick).
-				log.Printf("Started stream: %s\n", msg.Stream)
-			case "stopped":
-				// Stopped sending a specific stream (console, heap or tick).
-				log.Printf("Stopped stream: %s\n", msg.Stream)
-			case "connected":
-				// Stream has connected.
-				// Usually this message is sent after starting a stream.
-				// It may contain no additional data.
-				log.Printf("Connected: %s\n", msg.Data)
-			case "disconnected":
-				// Stream or server has disconnected.
-				log.Printf("Disconnected: %s\n", msg.Data)
-			case "backup_progress":
-				// Backup status.
-				// Backups are automatically made while playing or when the server is stopped.
-				var backupProgress aternos.BackupProgress
-				json.Unmarshal(msg.MessageBytes, &backupProgress)
-				log.Printf("Backup: %d/100%% complete (%s)\n", backupProgress.Progress, backupProgress.Action)
-			default:
-				data, _ := json.Marshal(msg)
-				fmt.Printf("Unhandled message '%s', skipping\nDump:%s\n", msg.Type, data)
-			}
-		// Stop server & quit when CTRL + C is pressed.
-		case <-interruptSignal:
-			if err = api.StopServer(); err != nil {
-				return
-			}
+	name, args := os.Args[1], os.Args[2:]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage()
+		os.Exit(exitOK)
+	}
+
+	for _, c := range commands {
+		if c.name != name {
+			continue
 		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := c.run(ctx, args)
+		stop()
+
+		if err != nil {
+			fail(err)
+		}
+		os.Exit(exitOK)
 	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n", name)
+	usage()
+	os.Exit(exitUsage)
 }
 
-// thisOrThat returns the first item that isn't an empty string.
-func thisOrThat(left, right string) string {
-	if left != "" {
-		return left
+// usageErrorf formats an error that indicates invalid usage of the CLI.
+func usageErrorf(format string, args ...interface{}) error {
+	return cli.UsageErrorf(format, args...)
+}
+
+// exitCode returns the exit code that corresponds to given error.
+func exitCode(err error) int {
+	var usageErr *cli.UsageError
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, aternos.UnauthenticatedError), errors.Is(err, aternos.ForbiddenError):
+		return exitAuth
+	case errors.Is(err, aternos.ServerAlreadyStartedError), errors.Is(err, aternos.ServerAlreadyStoppedError), errors.Is(err, aternos.ServerInQueueError), errors.Is(err, errNotInQueue):
+		return exitConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	default:
+		return exitError
+	}
+}
+
+// fail reports given error and exits with the corresponding exit code.
+func fail(err error) {
+	if !errors.Is(err, flag.ErrHelp) {
+		if jsonOutput {
+			printJSON(os.Stderr, map[string]interface{}{"error": err.Error(), "code": exitCode(err)})
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
 	}
-	return right
+	os.Exit(exitCode(err))
 }
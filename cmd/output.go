@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"io"
+	"os"
+)
+
+// jsonOutput is set by the -json flag.
+var jsonOutput bool
+
+// printJSON writes v as indented JSON.
+func printJSON(w io.Writer, v interface{}) {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	e.Encode(v)
+}
+
+// printJSONLine writes v as a single line of JSON, so that streams of values are output as JSON Lines.
+func printJSONLine(w io.Writer, v interface{}) {
+	json.NewEncoder(w).Encode(v)
+}
+
+// output writes v as JSON if -json is set, otherwise it calls human to write human readable text.
+func output(v interface{}, human func(w io.Writer)) {
+	if jsonOutput {
+		printJSON(os.Stdout, v)
+		return
+	}
+	human(os.Stdout)
+}
+
+// progress writes a progress message to stderr, unless JSON is being output.
+func progress(format string, args ...interface{}) {
+	if jsonOutput {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// globalFlags are the flags that every subcommand accepts.
+type globalFlags struct {
+	*cli.Flags
+	json *bool
+}
+
+// parseFlags parses the flags of a subcommand, including the global flags.
+func parseFlags(fs *flag.FlagSet, args []string) (*globalFlags, error) {
+	global := &globalFlags{
+		Flags: cli.AddFlags(fs),
+		json:  fs.Bool("json", false, "output JSON instead of human readable text"),
+	}
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, usageErrorf("%s", err)
+	}
+	jsonOutput = *global.json
+	return global, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPrintJSONLine(t *testing.T) {
+	var b bytes.Buffer
+	printJSONLine(&b, map[string]string{"line": "[Server] hello"})
+	printJSONLine(&b, map[string]string{"line": "{\n}"})
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", b.String())
+	}
+	for _, line := range lines {
+		var v map[string]string
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("line %q isn't a JSON object: %s", line, err)
+		}
+	}
+}
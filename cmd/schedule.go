@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	aternos "github.com/sleeyax/aternos-api"
+	"log"
+	"strings"
+	"time"
+)
+
+// stringsFlag is a flag that may be specified multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// scheduleCommand starts and stops the server according to cron expressions.
+func scheduleCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	var starts, stops stringsFlag
+	fs.Var(&starts, "start", "cron expression at which to start the server, e.g. \"0 18 * * FRI\" (can be repeated)")
+	fs.Var(&stops, "stop", "cron expression at which to stop the server, e.g. \"0 2 * * SAT\" (can be repeated)")
+	tz := fs.String("tz", "Local", "time zone of the cron expressions, e.g. Europe/Brussels")
+	state := fs.String("state", "aternos-schedule.json", "file to persist the schedule state in")
+	runMissed := fs.Bool("run-missed", false, "perform the most recent run that was missed within the last hour")
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(starts) == 0 && len(stops) == 0 {
+		return usageErrorf("specify at least one -start or -stop expression")
+	}
+
+	location, err := time.LoadLocation(*tz)
+	if err != nil {
+		return usageErrorf("invalid time zone: %s", err)
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	scheduler := aternos.NewScheduler(api)
+	scheduler.Location = location
+	scheduler.StateFile = *state
+	if *runMissed {
+		scheduler.Missed = aternos.RunLatestMissed
+	}
+
+	for _, spec := range starts {
+		scheduler.Rules = append(scheduler.Rules, aternos.ScheduleRule{Action: aternos.ScheduleStart, Spec: spec})
+	}
+	for _, spec := range stops {
+		scheduler.Rules = append(scheduler.Rules, aternos.ScheduleRule{Action: aternos.ScheduleStop, Spec: spec})
+	}
+
+	scheduler.OnRun = func(run aternos.ScheduleRun) {
+		switch {
+		case run.Skipped:
+			log.Printf("Skipped %s scheduled at %s\n", run.Rule.Action, run.Scheduled)
+		case run.Err != nil:
+			log.Printf("Failed to %s server: %s\n", run.Rule.Action, run.Err)
+		default:
+			log.Printf("Scheduled %s done.\n", run.Rule.Action)
+		}
+	}
+
+	log.Println("Running schedule, press CTRL + C to quit.")
+
+	if err = scheduler.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
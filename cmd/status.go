@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// statusCommand prints the server status.
+func statusCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	info, err := api.GetServerInfo()
+	if err != nil {
+		return err
+	}
+
+	output(info, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Name:\t%s\n", info.Name)
+		fmt.Fprintf(tw, "Status:\t%s\n", info.Status)
+		fmt.Fprintf(tw, "Address:\t%s\n", info.Address)
+		if info.DynIP != "" {
+			fmt.Fprintf(tw, "Dyn IP:\t%s\n", info.DynIP)
+		}
+		fmt.Fprintf(tw, "Port:\t%d\n", info.Port)
+		fmt.Fprintf(tw, "Software:\t%s %s\n", info.Software, info.Version)
+		fmt.Fprintf(tw, "Players:\t%d/%d\n", info.Players, info.MaxPlayers)
+		if info.Status == aternos.Preparing {
+			fmt.Fprintf(tw, "Queue:\t%d/%d (%s)\n", info.Queue.Position, info.Queue.Count, info.Queue.Time)
+		}
+		tw.Flush()
+	})
+
+	return nil
+}
+
+// playersCommand lists the players that are online.
+func playersCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("players", flag.ContinueOnError)
+	global, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	api, err := global.NewApi()
+	if err != nil {
+		return err
+	}
+
+	info, err := api.GetServerInfo()
+	if err != nil {
+		return err
+	}
+
+	players := info.PlayerList
+	if players == nil {
+		players = []string{}
+	}
+
+	output(map[string]interface{}{"players": players, "count": info.Players, "max": info.MaxPlayers}, func(w io.Writer) {
+		fmt.Fprintf(w, "%d/%d players online", info.Players, info.MaxPlayers)
+		if len(players) > 0 {
+			fmt.Fprintf(w, ": %s", strings.Join(players, ", "))
+		}
+		fmt.Fprintln(w)
+	})
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package aternos_api
+
+import "sync"
+
+// credentials manages the SEC and TOKEN values that authorize ajax requests.
+//
+// They're fetched lazily along with the server page, reused by subsequent requests
+// and refreshed once Aternos rejects them.
+type credentials struct {
+	mu sync.RWMutex
+
+	// refreshMu ensures only one refresh is in progress at a time.
+	refreshMu sync.Mutex
+
+	// ajax security token.
+	sec string
+
+	// ajax token.
+	token string
+
+	// Incremented each time the credentials are set.
+	// Zero means no credentials have been set yet.
+	generation uint64
+
+	// Whether the credentials may be used.
+	valid bool
+}
+
+// get returns the current credentials and their generation.
+func (c *credentials) get() (sec, token string, generation uint64, valid bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sec, c.token, c.generation, c.valid
+}
+
+// set replaces the current credentials.
+func (c *credentials) set(sec, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sec = sec
+	c.token = token
+	c.generation++
+	c.valid = true
+}
+
+// invalidate marks the credentials of given generation as invalid.
+// Nothing happens if the credentials have been refreshed since.
+func (c *credentials) invalidate(generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.valid = false
+	}
+}
+
+// ensureCredentials fetches new credentials if there are no valid ones.
+func (api *Api) ensureCredentials() error {
+	if _, _, _, valid := api.creds.get(); valid {
+		return nil
+	}
+
+	api.creds.refreshMu.Lock()
+	defer api.creds.refreshMu.Unlock()
+
+	// Another goroutine may have refreshed the credentials while we were waiting.
+	if _, _, _, valid := api.creds.get(); valid {
+		return nil
+	}
+
+	api.logger.Debug("refreshing ajax credentials")
+
+	_, err := api.GetServerInfo()
+
+	return err
+}
@@ -0,0 +1,154 @@
+package aternos_api
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestApi_ajax_refreshesCredentials(t *testing.T) {
+	var pages, tokens int32
+
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/server":
+			atomic.AddInt32(&pages, 1)
+			w.Write([]byte(testServerPage(`{"status":10}`, "fresh-token")))
+		case "/ajax/server/confirm":
+			if r.URL.Query().Get("TOKEN") != "fresh-token" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			atomic.AddInt32(&tokens, 1)
+			w.Write([]byte(`{"success":true}`))
+		}
+	})
+
+	// The initial credentials are rejected, so they must be refreshed exactly once.
+	if err := api.confirm(); err != nil {
+		t.Fatal(err)
+	}
+	if pages != 1 || tokens != 1 {
+		t.Fatalf("expected 1 page and 1 accepted token, got %d and %d", pages, tokens)
+	}
+
+	// Valid credentials are reused.
+	if err := api.confirm(); err != nil {
+		t.Fatal(err)
+	}
+	if pages != 1 {
+		t.Fatalf("expected credentials to be reused, fetched %d pages", pages)
+	}
+}
+
+func TestApi_ajax_badRequest(t *testing.T) {
+	var pages, requests int32
+
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/server":
+			atomic.AddInt32(&pages, 1)
+			w.Write([]byte(testServerPage(`{"status":10}`, "token")))
+		default:
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	// Credentials that keep being rejected are refreshed only once.
+	if err := api.confirm(); !errors.Is(err, InvalidTokenError) {
+		t.Fatalf("expected invalid token error, got %v", err)
+	}
+	if pages != 1 || requests != 2 {
+		t.Fatalf("expected 1 refresh and 2 requests, got %d and %d", pages, requests)
+	}
+}
+
+func TestApi_ensureCredentials_concurrent(t *testing.T) {
+	var pages int32
+
+	api := newTestApi(t, &Options{}, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pages, 1)
+		w.Write([]byte(testServerPage(`{"status":0}`, "token")))
+	})
+	_, _, generation, _ := api.creds.get()
+	api.creds.invalidate(generation)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := api.ensureCredentials(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if pages != 1 {
+		t.Fatalf("expected credentials to be fetched once, fetched %d pages", pages)
+	}
+}
+
+func TestCredentials_invalidate(t *testing.T) {
+	var creds credentials
+
+	creds.set("sec", "old")
+	_, _, stale, _ := creds.get()
+	creds.set("sec", "new")
+
+	// A request that failed with outdated credentials must not invalidate the new ones.
+	creds.invalidate(stale)
+	if _, token, _, valid := creds.get(); !valid || token != "new" {
+		t.Fatalf("expected new credentials to stay valid, got %q (valid: %t)", token, valid)
+	}
+}
+
+func TestApi_GetServerInfo_keepsSec(t *testing.T) {
+	api := newTestApi(t, &Options{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testServerPage(`{"status":0}`, "fresh-token")))
+	})
+
+	// Valid credentials only get a new TOKEN.
+	for i := 0; i < 3; i++ {
+		if _, err := api.GetServerInfo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sec, token, _, _ := api.creds.get(); sec != "sec" || token != "fresh-token" {
+		t.Fatalf("expected SEC to be kept and TOKEN to be refreshed, got %q and %q", sec, token)
+	}
+	if cookies := api.GetCookies(); len(cookies) != 0 {
+		t.Fatalf("expected no SEC cookies, got %d", len(cookies))
+	}
+
+	// Rejected credentials get a new SEC, which replaces the cookie of the previous one.
+	var secs []string
+	for i := 0; i < 3; i++ {
+		_, _, generation, _ := api.creds.get()
+		api.creds.invalidate(generation)
+		if _, err := api.GetServerInfo(); err != nil {
+			t.Fatal(err)
+		}
+		sec, _, _, _ := api.creds.get()
+		secs = append(secs, sec)
+	}
+	if secs[0] == "sec" || secs[0] == secs[1] || secs[1] == secs[2] {
+		t.Fatalf("expected a new SEC after each invalidation, got %q", secs)
+	}
+	if cookies := api.GetCookies(); len(cookies) != 1 {
+		t.Fatalf("expected 1 SEC cookie, got %d", len(cookies))
+	}
+
+	// Checking the status before stopping doesn't touch the credentials.
+	_, _, before, _ := api.creds.get()
+	if err := api.StopServer(); !errors.Is(err, ServerAlreadyStoppedError) {
+		t.Fatalf("expected already stopped error, got %v", err)
+	}
+	if _, _, after, _ := api.creds.get(); after != before {
+		t.Fatal("expected the status check to leave the credentials untouched")
+	}
+}
@@ -1,15 +1,143 @@
 package aternos_api
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 var (
 	ServerAlreadyStartedError = errors.New("server already started")
 
 	ServerAlreadyStoppedError = errors.New("server already stopped")
 
+	// ServerInQueueError indicates that the server is already waiting in queue.
+	ServerInQueueError = errors.New("server in queue")
+
+	// EulaNotAcceptedError indicates that the Minecraft EULA must be accepted before the server can be started.
+	EulaNotAcceptedError = errors.New("eula not accepted")
+
+	// WrongVersionError indicates that the installed software version is incorrect.
+	WrongVersionError = errors.New("wrong software version installed")
+
+	// FileServerUnavailableError indicates that the Aternos file server is unavailable.
+	// See https://status.aternos.gmbh.
+	FileServerUnavailableError = errors.New("file server unavailable")
+
+	// StorageLimitError indicates that the storage size limit of the server has been reached.
+	StorageLimitError = errors.New("storage size limit reached")
+
+	// ServerNotStartedError indicates that the server didn't leave the Offline status in time after it was started.
+	ServerNotStartedError = errors.New("server didn't start")
+
 	// UnauthenticatedError indicates an invalid account was used to request the resource.
 	UnauthenticatedError = errors.New("unauthenticated (invalid account)")
 
 	// ForbiddenError indicates that the request was blocked by CloudFlare.
 	ForbiddenError = errors.New("forbidden (blocked by CloudFlare)")
+
+	// InvalidTokenError indicates that Aternos rejected the SEC or TOKEN credentials of an ajax request.
+	InvalidTokenError = errors.New("invalid ajax token")
 )
+
+// startErrorCodes maps the error codes that Aternos sends when it refuses to start the server to sentinel errors.
+var startErrorCodes = map[string]error{
+	"already":      ServerAlreadyStartedError,
+	"eula":         EulaNotAcceptedError,
+	"wrongversion": WrongVersionError,
+	"file":         FileServerUnavailableError,
+	"size":         StorageLimitError,
+}
+
+// AjaxError is returned when Aternos rejects an ajax request.
+type AjaxError struct {
+	// Endpoint that was requested.
+	// E.g. "ajax/server/start".
+	Endpoint string
+
+	// HTTP status code of the response.
+	StatusCode int
+
+	// Error code that Aternos sent, if any.
+	// E.g. "eula". The codes that the start and stop endpoints are known to send are mapped to sentinel errors,
+	// inspect Code and Message to handle others.
+	Code string
+
+	// Error message that Aternos sent, if any.
+	Message string
+
+	// Raw response body.
+	Body []byte
+}
+
+// newAjaxError creates an AjaxError from given response status code and body.
+func newAjaxError(endpoint string, statusCode int, body []byte) *AjaxError {
+	e := &AjaxError{Endpoint: endpoint, StatusCode: statusCode, Body: body}
+
+	var payload struct {
+		Error   interface{} `json:"error"`
+		Message interface{} `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		if code, ok := payload.Error.(string); ok {
+			e.Code = code
+		}
+		if message, ok := payload.Message.(string); ok {
+			e.Message = message
+		}
+	}
+
+	return e
+}
+
+func (e *AjaxError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: ", e.Endpoint)
+
+	switch {
+	case e.Message != "":
+		b.WriteString(e.Message)
+	case e.Code != "":
+		b.WriteString(e.Code)
+	case e.StatusCode == http.StatusOK:
+		b.WriteString("request unsuccessful")
+	default:
+		fmt.Fprintf(&b, "unexpected HTTP status code %d (%s)", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	if e.Code != "" && e.Message != "" {
+		fmt.Fprintf(&b, " (%s)", e.Code)
+	}
+
+	return b.String()
+}
+
+// Is reports whether the error corresponds to one of the sentinel errors in this package,
+// so that it can be used with errors.Is.
+func (e *AjaxError) Is(target error) bool {
+	switch e.Endpoint {
+	case "ajax/server/start":
+		if err, ok := startErrorCodes[e.Code]; ok {
+			return target == err
+		}
+	case "ajax/server/stop":
+		if e.Code == "already" {
+			return target == ServerAlreadyStoppedError
+		}
+	}
+
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		// Aternos rejects stale SEC and TOKEN credentials with a bare 400 Bad Request.
+		return target == InvalidTokenError && strings.HasPrefix(e.Endpoint, "ajax/")
+	case http.StatusForbidden:
+		return target == ForbiddenError
+	case http.StatusUnauthorized:
+		return target == UnauthenticatedError
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package aternos_api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAjaxError_Is(t *testing.T) {
+	tests := []struct {
+		err    *AjaxError
+		target error
+	}{
+		{newAjaxError("ajax/server/start", 200, []byte(`{"success":false,"error":"already"}`)), ServerAlreadyStartedError},
+		{newAjaxError("ajax/server/stop", 200, []byte(`{"success":false,"error":"already"}`)), ServerAlreadyStoppedError},
+		{newAjaxError("ajax/server/start", 200, []byte(`{"success":false,"error":"eula"}`)), EulaNotAcceptedError},
+		{newAjaxError("ajax/server/start", 200, []byte(`{"success":false,"error":"wrongversion"}`)), WrongVersionError},
+		{newAjaxError("ajax/server/start", 200, []byte(`{"success":false,"error":"file"}`)), FileServerUnavailableError},
+		{newAjaxError("ajax/server/start", 200, []byte(`{"success":false,"error":"size"}`)), StorageLimitError},
+		{newAjaxError("ajax/server/confirm", 400, nil), InvalidTokenError},
+		{newAjaxError("ajax/server/start", 401, nil), UnauthenticatedError},
+		{newAjaxError("ajax/server/start", 403, []byte(`<html></html>`)), ForbiddenError},
+	}
+
+	for _, test := range tests {
+		if !errors.Is(test.err, test.target) {
+			t.Errorf("expected %q to be %q", test.err, test.target)
+		}
+	}
+
+	// Codes are specific to the endpoint that sent them.
+	for _, err := range []*AjaxError{
+		newAjaxError("ajax/server/confirm", 200, []byte(`{"success":false,"error":"already"}`)),
+		newAjaxError("ajax/server/stop", 200, []byte(`{"success":false,"error":"eula"}`)),
+		newAjaxError("server", 400, nil),
+	} {
+		for _, target := range []error{ServerAlreadyStartedError, ServerAlreadyStoppedError, EulaNotAcceptedError, InvalidTokenError} {
+			if errors.Is(err, target) {
+				t.Errorf("expected %q not to be %q", err, target)
+			}
+		}
+	}
+}
+
+func TestAjaxError_Error(t *testing.T) {
+	err := newAjaxError("ajax/server/start", 200, []byte(`{"success":false,"error":"eula","message":"Accept the EULA first."}`))
+	if s := err.Error(); s != "ajax/server/start: Accept the EULA first. (eula)" {
+		t.Fatalf("unexpected error message %q", s)
+	}
+
+	err = newAjaxError("ajax/server/start", 400, []byte("Bad Request"))
+	if s := err.Error(); s != "ajax/server/start: unexpected HTTP status code 400 (Bad Request)" {
+		t.Fatalf("unexpected error message %q", s)
+	}
+}
+
+func TestApi_ajax(t *testing.T) {
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ajax/server/confirm":
+			w.Write([]byte(`{"success":true}`))
+		case "/ajax/server/start":
+			w.Write([]byte(`{"success":false,"error":"eula"}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	if err := api.ajax(request{path: "ajax/server/confirm", ajax: true, idempotent: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := api.ajax(request{path: "ajax/server/start", ajax: true})
+	var ajaxErr *AjaxError
+	if !errors.As(err, &ajaxErr) || ajaxErr.Code != "eula" || !errors.Is(err, EulaNotAcceptedError) {
+		t.Fatalf("expected eula error, got %v", err)
+	}
+
+	err = api.ajax(request{path: "ajax/server/stop", ajax: true, idempotent: true})
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !errors.As(err, &ajaxErr) || ajaxErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected bad gateway error after retrying, got %v", err)
+	}
+}
+
+func TestApi_StartServer_StopServer_status(t *testing.T) {
+	var status string
+	var requests int
+
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/server":
+			w.Write([]byte(testServerPage(`{"status":`+status+`}`, "token")))
+		default:
+			requests++
+			w.Write([]byte(`{"success":true}`))
+		}
+	})
+
+	status = "1"
+	if err := api.StartServer(); err != ServerAlreadyStartedError {
+		t.Fatalf("expected already started error, got %v", err)
+	}
+
+	status = "0"
+	if err := api.StopServer(); err != ServerAlreadyStoppedError {
+		t.Fatalf("expected already stopped error, got %v", err)
+	}
+
+	status = "10"
+	if err := api.StartServer(); err != ServerInQueueError {
+		t.Fatalf("expected in queue error, got %v", err)
+	}
+
+	if requests != 0 {
+		t.Fatalf("expected no ajax requests, got %d", requests)
+	}
+
+	status = "0"
+
+	if err := api.StartServer(); err != nil || requests != 1 {
+		t.Fatalf("expected server to be started, got %v after %d request(s)", err, requests)
+	}
+}
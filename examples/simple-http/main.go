@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	aternos "github.com/sleeyax/aternos-api"
 	"log"
 	"net/http"
@@ -44,12 +45,19 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	// Confirm the server.
-	log.Println("Confirming...")
-	if err := api.ConfirmServer(nil, delay); err != nil {
+	// Confirm the server whenever it's our turn in queue, until it has left the queue.
+	log.Println("Waiting in queue...")
+	confirmer := aternos.NewAutoConfirmer(api)
+	confirmer.OnConfirm = func(confirmation aternos.Confirmation) {
+		if confirmation.Err != nil {
+			log.Println("failed to confirm:", confirmation.Err)
+			return
+		}
+		log.Println("Confirmed!")
+	}
+	if err := confirmer.Run(context.Background()); err != nil {
 		log.Fatalln(err)
 	}
-	log.Println("Confirmed!")
 
 	// Wit until the server is online.
 	var info aternos.ServerInfo
@@ -0,0 +1,106 @@
+package aternos_api
+
+import (
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAternos is a fake Aternos server for the types that control the server in the background.
+//
+// It serves the server page with the current status, counts the requests to each ajax endpoint
+// and records the console commands that are sent over its websocket.
+type fakeAternos struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	status   string
+	requests map[string]int
+	failures map[string]int
+
+	commands chan string
+}
+
+// newFakeAternos returns a fake of which the server has given status, e.g. `{"status":1}`.
+func newFakeAternos(t *testing.T, status string) *fakeAternos {
+	return &fakeAternos{
+		t:        t,
+		status:   status,
+		requests: make(map[string]int),
+		failures: make(map[string]int),
+		commands: make(chan string, 10),
+	}
+}
+
+// newApi returns an Api that sends its requests to the fake.
+func (f *fakeAternos) newApi() *Api {
+	return newTestApi(f.t, &Options{RetryPolicy: testRetryPolicy()}, f.serveHTTP)
+}
+
+// newWebsocket returns a websocket connection of which the console commands are recorded by the fake.
+func (f *fakeAternos) newWebsocket() *Websocket {
+	wss := newTestWebsocket(f.t, func(conn *websocket.Conn) {
+		for {
+			var msg struct {
+				Type string `json:"type"`
+				Data string `json:"data"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Type == "command" {
+				f.commands <- msg.Data
+			}
+		}
+	})
+	f.t.Cleanup(func() { wss.Close() })
+	return wss
+}
+
+func (f *fakeAternos) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/server" {
+		w.Write([]byte(testServerPage(f.status, "token")))
+		return
+	}
+
+	endpoint := strings.TrimPrefix(r.URL.Path, "/ajax/server/")
+	f.requests[endpoint]++
+	if f.failures[endpoint] > 0 {
+		f.failures[endpoint]--
+		w.Write([]byte(`{"success":false}`))
+		return
+	}
+	w.Write([]byte(`{"success":true}`))
+}
+
+// fail makes the next n requests to given ajax endpoint, e.g. "stop", unsuccessful.
+func (f *fakeAternos) fail(endpoint string, n int) {
+	f.mu.Lock()
+	f.failures[endpoint] += n
+	f.mu.Unlock()
+}
+
+// count returns the amount of requests to given ajax endpoint, e.g. "start".
+func (f *fakeAternos) count(endpoint string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[endpoint]
+}
+
+// command returns the next console command that was sent over the websocket.
+func (f *fakeAternos) command() string {
+	f.t.Helper()
+	select {
+	case command := <-f.commands:
+		return command
+	case <-time.After(5 * time.Second):
+		f.t.Fatal("timed out waiting for command")
+		return ""
+	}
+}
@@ -6,17 +6,180 @@ import (
 	"errors"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
-	"github.com/dop251/goja"
-	"log"
+	"github.com/sleeyax/gotcha"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 )
 
+// request describes a single HTTP request to Aternos.
+type request struct {
+	// Path of the resource, relative to the Aternos base URL.
+	// E.g. "ajax/server/start".
+	path string
+
+	// Optional query string, excluding the SEC and TOKEN parameters.
+	query string
+
+	// Whether the SEC and TOKEN parameters must be appended to the query string.
+	ajax bool
+
+	// Whether sending the request more than once has the same effect as sending it once.
+	idempotent bool
+
+	// settled reports whether a failed attempt of a non-idempotent request has taken effect regardless.
+	// It's called before retrying such a request; no retry is made when it returns true.
+	settled func() (bool, error)
+}
+
+// url returns the URL of the request, relative to the Aternos base URL.
+func (r request) url(sec, token string) string {
+	query := r.query
+	if r.ajax {
+		if query != "" {
+			query += "&"
+		}
+		query += fmt.Sprintf("SEC=%s&TOKEN=%s", sec, token)
+	}
+
+	if query == "" {
+		return r.path
+	}
+
+	return r.path + "?" + query
+}
+
+// errSettled is returned by Api.do when a non-idempotent request failed, but turned out to have taken effect.
+var errSettled = errors.New("request settled")
+
+// do sends the request, retrying it on transient failures according to the configured RetryPolicy.
+//
+// A response with a status code that isn't considered transient is returned as is, even if it indicates an error.
+func (api *Api) do(req request) (*gotcha.Response, error) {
+	policy := api.Options.RetryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Requests can't be cancelled yet, so waiting doesn't fail.
+		api.limiters.wait(context.Background(), req.path)
+
+		sec, token, _, _ := api.creds.get()
+
+		api.clientMu.Lock()
+		started := time.Now()
+		res, err := api.client.Get(req.url(sec, token))
+		if tracer := api.Options.Tracer; tracer != nil {
+			tracer.traceHTTP(api.client.Options, started, res, err)
+		}
+		api.clientMu.Unlock()
+
+		api.stats.record(req.path, attempt, err != nil || res.StatusCode >= http.StatusBadRequest)
+
+		if err != nil {
+			api.logger.Debug("request failed", "endpoint", req.path, "attempt", attempt, "error", err)
+		} else {
+			api.logger.Debug("request done", "endpoint", req.path, "attempt", attempt, "status", res.StatusCode)
+		}
+
+		// Whether the request was rejected before Aternos processed it.
+		var rejected bool
+
+		if err == nil {
+			if res.StatusCode == http.StatusTooManyRequests {
+				rejected = true
+				if d := retryAfter(res.Header); d > 0 {
+					api.limiters.pause(d)
+				}
+			} else if !policy.retryableStatus(res.StatusCode) {
+				return res, nil
+			}
+			body, _ := res.Raw()
+			res.Close()
+			err = newAjaxError(req.path, res.StatusCode, body)
+		} else if !policy.retryableError(err) {
+			return nil, &RetryError{Endpoint: req.path, Attempts: attempt, Err: err}
+		} else {
+			rejected = isDialError(err)
+		}
+
+		if attempt >= policy.MaxAttempts {
+			return nil, &RetryError{Endpoint: req.path, Attempts: attempt, Err: err}
+		}
+
+		// Only retry a non-idempotent request if it surely didn't reach Aternos or doesn't appear to have taken effect.
+		if !req.idempotent && !rejected {
+			if req.settled == nil {
+				return nil, &RetryError{Endpoint: req.path, Attempts: attempt, Err: err}
+			}
+			if ok, e := req.settled(); e != nil {
+				return nil, &RetryError{Endpoint: req.path, Attempts: attempt, Err: err}
+			} else if ok {
+				return nil, errSettled
+			}
+		}
+
+		delay := policy.delay(attempt)
+		api.logger.Warn("retrying request", "endpoint", req.path, "attempt", attempt, "delay", delay, "error", err)
+		time.Sleep(delay)
+	}
+}
+
+// ajax sends an ajax request and checks whether Aternos reports it to be successful.
+//
+// The SEC and TOKEN credentials are fetched if needed and refreshed once when Aternos rejects them.
+func (api *Api) ajax(req request) error {
+	for refreshed := false; ; refreshed = true {
+		if err := api.ensureCredentials(); err != nil {
+			return err
+		}
+
+		_, _, generation, _ := api.creds.get()
+
+		err := api.sendAjax(req)
+		if errors.Is(err, InvalidTokenError) && !refreshed {
+			api.logger.Info("ajax credentials rejected", "endpoint", req.path, "error", err)
+			api.creds.invalidate(generation)
+			continue
+		}
+
+		return err
+	}
+}
+
+// sendAjax sends an ajax request with the current credentials and checks whether Aternos reports it to be successful.
+func (api *Api) sendAjax(req request) error {
+	res, err := api.do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Close()
+
+	body, err := res.Raw()
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return newAjaxError(req.path, res.StatusCode, body)
+	}
+
+	var payload struct {
+		Success *bool `json:"success"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Success != nil && !*payload.Success {
+		return newAjaxError(req.path, res.StatusCode, body)
+	}
+
+	return nil
+}
+
 // getDocument sends a GET request to the specified url and reads the response as a goquery.Document.
 func (api *Api) getDocument(url string) (*goquery.Document, error) {
-	res, err := api.client.Get(url)
+	res, err := api.do(request{path: url, idempotent: true})
 
 	if err != nil {
 		return nil, err
@@ -32,57 +195,64 @@ func (api *Api) getDocument(url string) (*goquery.Document, error) {
 	return document, nil
 }
 
-// genSec generates a security token called SEC.
-func (api *Api) genSec() {
+// genSec generates a security token called SEC and sets its cookie.
+// The cookie of given previous SEC, if any, is removed so that the cookie header doesn't keep growing.
+func (api *Api) genSec(previous string) string {
 	key := randomString(11) + "00000"
 	value := randomString(11) + "00000"
 
-	api.sec = fmt.Sprintf("%s:%s", key, value)
-	api.client.Options.CookieJar.SetCookies(api.client.Options.FullUrl, []*http.Cookie{
+	cookies := []*http.Cookie{
 		{
 			Name:  fmt.Sprintf("ATERNOS_SEC_%s", key),
 			Value: value,
 		},
-	})
-}
+	}
+	if i := strings.Index(previous, ":"); i > 0 {
+		cookies = append(cookies, &http.Cookie{
+			Name:   fmt.Sprintf("ATERNOS_SEC_%s", previous[:i]),
+			MaxAge: -1,
+		})
+	}
 
-// extractAjaxToken extracts and unpacks the AJAX TOKEN from given HTML document.
-func (api *Api) extractAjaxToken(document *goquery.Document) error {
-	var script string
+	api.clientMu.Lock()
+	defer api.clientMu.Unlock()
 
-	document.Find("script[type='text/javascript']").EachWithBreak(func(i int, selection *goquery.Selection) bool {
-		script = strings.TrimSpace(selection.Text())
-		return !strings.Contains(script, "window")
-	})
-
-	if script == "" {
-		return errors.New("failed to find token")
-	}
+	u, _ := url.Parse(api.client.Options.PrefixURL)
+	api.client.Options.CookieJar.SetCookies(u, cookies)
 
-	vm := goja.New()
+	return fmt.Sprintf("%s:%s", key, value)
+}
 
-	if err := vm.Set("atob", atob); err != nil {
-		return err
+// GetServerInfo fetches all server information over HTTP.
+// It also refreshes the TOKEN that is used to authorize ajax requests.
+// The SEC is kept, unless the credentials have been rejected, in which case a new one is generated.
+func (api *Api) GetServerInfo() (ServerInfo, error) {
+	info, document, err := api.getServerInfo()
+	if err != nil {
+		return info, err
 	}
 
-	window := "{document: {}, setTimeout: (f, t) => {}, setInterval: (f, i) => {}, clearTimeout: (f) => {}, clearInterval: (f) => {}, Map: () => {}}"
-	script = fmt.Sprintf("window = %s; %s window['AJAX_TOKEN'];", window, script)
-
-	v, err := vm.RunString(script)
+	// The page also contains the ajax token, so refresh the credentials while we're at it.
+	token, err := api.extractAjaxToken(document)
 	if err != nil {
-		return err
+		return info, err
 	}
 
-	api.token = v.String()
+	sec, _, _, valid := api.creds.get()
+	if sec == "" || !valid {
+		sec = api.genSec(sec)
+	}
+	api.creds.set(sec, token)
 
-	return nil
+	return info, nil
 }
 
-// GetServerInfo fetches all server information over HTTP.
-func (api *Api) GetServerInfo() (ServerInfo, error) {
+// getServerInfo fetches the server page and reads the server information from it.
+// Unlike GetServerInfo, it leaves the credentials untouched.
+func (api *Api) getServerInfo() (ServerInfo, *goquery.Document, error) {
 	document, err := api.getDocument("server")
 	if err != nil {
-		return ServerInfo{}, err
+		return ServerInfo{}, nil, err
 	}
 
 	var script string
@@ -96,55 +266,47 @@ func (api *Api) GetServerInfo() (ServerInfo, error) {
 	})
 
 	if script == "" {
-		return ServerInfo{}, errors.New("failed to find server info")
+		return ServerInfo{}, nil, errors.New("failed to find server info")
 	}
 
 	data := strings.TrimSuffix(strings.ReplaceAll(script, prefix, ""), suffix)
 	if err = json.Unmarshal([]byte(data), &info); err != nil {
-		return ServerInfo{}, err
+		return ServerInfo{}, nil, err
 	}
 
-	api.genSec()
-	err = api.extractAjaxToken(document)
-
-	return info, err
+	return info, document, nil
 }
 
 // StartServer starts your Minecraft server over HTTP.
+//
+// ServerAlreadyStartedError is returned if the server is already online and ServerInQueueError if it's waiting in queue.
 func (api *Api) StartServer() error {
-	info, err := api.GetServerInfo()
+	info, _, err := api.getServerInfo()
 	if err != nil {
 		return err
 	}
 
-	if info.Status == Online {
+	switch info.Status {
+	case Online:
 		return ServerAlreadyStartedError
+	case Preparing:
+		return ServerInQueueError
 	}
 
-	res, err := api.client.Get(fmt.Sprintf("ajax/server/start?headstart=false&access-credits=false&SEC=%s&TOKEN=%s", api.sec, api.token))
-	if err != nil {
-		return err
-	}
-
-	defer res.Close()
-
-	if res.StatusCode != 200 {
-		msg := fmt.Sprintf("Unexpected HTTP status code %d (%s)", res.StatusCode, res.Status)
-		fmt.Println(msg)
-		fmt.Println("Response body:")
-		fmt.Println(res.Text())
-		return errors.New(msg)
-	}
-
-	json, err := res.Json()
-	if err != nil {
-		return err
-	}
-	if json["success"] == false {
-		return errors.New("Aternos failed to start the server.")
+	err = api.ajax(request{
+		path:  "ajax/server/start",
+		query: "headstart=false&access-credits=false",
+		ajax:  true,
+		settled: func() (bool, error) {
+			info, _, err := api.getServerInfo()
+			return info.Status != Offline, err
+		},
+	})
+	if errors.Is(err, errSettled) {
+		return nil
 	}
 
-	return nil
+	return err
 }
 
 // ConfirmServer sends a confirmation over HTTP to claim that 'you're still active'.
@@ -154,6 +316,9 @@ func (api *Api) StartServer() error {
 //
 // Delay specifies the amount of seconds to wait before submitting the next confirmation.
 // Recommended to wait time is around 10 seconds.
+//
+// Deprecated: ConfirmServer returns after a single confirmation and skips the status check when a context is given.
+// Use AutoConfirmer instead, which confirms exactly when it's the server's turn in queue.
 func (api *Api) ConfirmServer(ctx context.Context, delay time.Duration) error {
 	isAsync := ctx != nil
 
@@ -176,7 +341,7 @@ func (api *Api) ConfirmServer(ctx context.Context, delay time.Duration) error {
 				info, err = api.GetServerInfo()
 				if err != nil {
 					if isAsync {
-						log.Println("Failed to get server info while confirming server:", err)
+						api.logger.Error("failed to get server info while confirming server", "error", err)
 					}
 					return err
 				}
@@ -187,24 +352,32 @@ func (api *Api) ConfirmServer(ctx context.Context, delay time.Duration) error {
 				break
 			}
 
-			res, err := api.client.Get(fmt.Sprintf("ajax/server/confirm?headstart=false&access-credits=false&SEC=%s&TOKEN=%s", api.sec, api.token))
-			if err != nil {
-				if isAsync {
-					log.Println("Failed to confirm server:", err)
-				}
-				return err
+			err := api.confirm()
+			if err != nil && isAsync {
+				api.logger.Error("failed to confirm server", "endpoint", "ajax/server/confirm", "error", err)
 			}
-			res.Close()
 
-			return nil
+			return err
 		}
 	}
 }
 
+// confirm confirms the server once.
+func (api *Api) confirm() error {
+	return api.ajax(request{
+		path:       "ajax/server/confirm",
+		query:      "headstart=false&access-credits=false",
+		ajax:       true,
+		idempotent: true,
+	})
+}
+
 // StopServer stops the Minecraft server over HTTP.
 // This function doesn't wait until the server is fully stopped, it only requests a shutdown.
+//
+// ServerAlreadyStoppedError is returned if the server is already offline.
 func (api *Api) StopServer() error {
-	info, err := api.GetServerInfo()
+	info, _, err := api.getServerInfo()
 	if err != nil {
 		return err
 	}
@@ -213,15 +386,16 @@ func (api *Api) StopServer() error {
 		return ServerAlreadyStoppedError
 	}
 
-	_, err = api.client.Get(fmt.Sprintf("ajax/server/stop?SEC=%s&TOKEN=%s", api.sec, api.token))
-
-	return err
+	return api.ajax(request{path: "ajax/server/stop", ajax: true, idempotent: true})
 }
 
 // GetCookies returns the current authentication cookies that are being used.
 //
 // You can use this function to export them (to for example a .txt file) so you can resume the session later.
 func (api *Api) GetCookies() []*http.Cookie {
+	api.clientMu.Lock()
+	defer api.clientMu.Unlock()
+
 	u, _ := url.Parse(api.client.Options.PrefixURL)
 	return api.client.Options.CookieJar.Cookies(u)
 }
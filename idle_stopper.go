@@ -0,0 +1,212 @@
+package aternos_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IdleStopper stops the server once nobody has been online for a while.
+//
+// It counts down as soon as the server is online without players, broadcasts warnings to the console
+// while counting down and calls Api.StopServer once the countdown ends.
+// The countdown is cancelled when someone joins or the server stops in the meantime.
+type IdleStopper struct {
+	// Time the server must be empty before it's stopped.
+	IdleTimeout time.Duration
+
+	// Times before the server is stopped at which a warning is broadcast, e.g. 1 minute and 10 seconds.
+	// Warnings that are longer than IdleTimeout are skipped.
+	Warnings []time.Duration
+
+	// Returns the console command that broadcasts a warning that the server stops after given duration.
+	Warning func(remaining time.Duration) string
+
+	// Console command that announces that the countdown was cancelled.
+	// It's only sent if a warning has been broadcast. Empty means nothing is sent.
+	Cancelled string
+
+	// Time to wait before retrying to stop the server after Api.StopServer failed.
+	// It doubles after each consecutive failure, up to IdleTimeout.
+	RetryDelay time.Duration
+
+	// Called after each attempt to stop the server, with the error of Api.StopServer.
+	// It's called from the goroutine that executes Run.
+	OnStop func(err error)
+
+	api *Api
+	wss *Websocket
+
+	mu      sync.Mutex
+	latest  *ServerInfo
+	updates chan struct{}
+}
+
+// NewIdleStopper allocates a new IdleStopper with default settings.
+// Status changes and warnings are received and sent through given websocket connection.
+func NewIdleStopper(api *Api, wss *Websocket) *IdleStopper {
+	return &IdleStopper{
+		IdleTimeout: 10 * time.Minute,
+		Warnings:    []time.Duration{5 * time.Minute, time.Minute, 10 * time.Second},
+		Warning: func(remaining time.Duration) string {
+			return fmt.Sprintf("say Nobody is online, the server stops in %s", remaining)
+		},
+		Cancelled:  "say Someone joined, the server keeps running",
+		RetryDelay: 30 * time.Second,
+		api:        api,
+		wss:        wss,
+		updates:    make(chan struct{}, 1),
+	}
+}
+
+// HandleMessage implements MessageHandler.
+// It observes status messages.
+func (s *IdleStopper) HandleMessage(msg WebsocketMessage) {
+	if msg.Type != "status" {
+		return
+	}
+
+	if info, err := msg.serverInfo(); err == nil {
+		s.observe(info)
+	}
+}
+
+// observe stores given server info as the most recent one and wakes up Run.
+func (s *IdleStopper) observe(info ServerInfo) {
+	s.mu.Lock()
+	s.latest = &info
+	s.mu.Unlock()
+
+	select {
+	case s.updates <- struct{}{}:
+	default:
+	}
+}
+
+// Run watches the server until ctx is done, stopping it each time it has been empty for IdleTimeout.
+// A failed stop is retried after RetryDelay for as long as the server stays empty.
+//
+// It returns ctx.Err() when ctx is done, or an error when the websocket connection is closed.
+func (s *IdleStopper) Run(ctx context.Context) error {
+	remove := s.wss.AddHandler(s)
+	defer remove()
+
+	// Fetch the current status, since status messages are only sent on changes.
+	if info, err := s.api.GetServerInfo(); err == nil {
+		s.mu.Lock()
+		if s.latest == nil {
+			s.latest = &info
+		}
+		s.mu.Unlock()
+	} else {
+		s.api.logger.Warn("failed to get initial server status", "error", err)
+	}
+
+	warnings := append([]time.Duration(nil), s.Warnings...)
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i] > warnings[j]
+	})
+
+	var (
+		// End of the countdown, zero if not counting down.
+		deadline time.Time
+
+		// Amount of warnings that have been handled.
+		warned int
+
+		// Whether a warning has been broadcast during this countdown.
+		broadcast bool
+
+		// Whether the server has been stopped and hasn't changed status since.
+		stopped bool
+
+		// Delay before the next attempt after a failed stop, zero if the last attempt didn't fail.
+		retryDelay time.Duration
+
+		wake <-chan time.Time
+	)
+
+	for {
+		s.mu.Lock()
+		latest := s.latest
+		s.mu.Unlock()
+
+		now := time.Now()
+
+		if latest == nil || latest.Status != Online || latest.Players > 0 {
+			if !deadline.IsZero() {
+				s.api.logger.Info("idle countdown cancelled")
+				if broadcast && s.Cancelled != "" {
+					s.command(s.Cancelled)
+				}
+			}
+			deadline, wake, stopped, retryDelay = time.Time{}, nil, false, 0
+		} else if !stopped {
+			if deadline.IsZero() {
+				deadline = now.Add(s.IdleTimeout)
+				warned, broadcast = 0, false
+				for warned < len(warnings) && warnings[warned] >= s.IdleTimeout {
+					warned++
+				}
+				s.api.logger.Info("server is empty, counting down", "timeout", s.IdleTimeout)
+			}
+
+			for warned < len(warnings) && !now.Before(deadline.Add(-warnings[warned])) {
+				// Skip warnings that are already overdue, e.g. after the process was suspended.
+				if warned == len(warnings)-1 || now.Before(deadline.Add(-warnings[warned+1])) {
+					s.command(s.Warning(warnings[warned]))
+					broadcast = true
+				}
+				warned++
+			}
+
+			if !now.Before(deadline) {
+				s.api.logger.Info("stopping idle server")
+				err := s.api.StopServer()
+				if s.OnStop != nil {
+					s.OnStop(err)
+				}
+
+				if err == nil || errors.Is(err, ServerAlreadyStoppedError) {
+					deadline, wake, stopped, retryDelay = time.Time{}, nil, true, 0
+				} else {
+					if retryDelay == 0 {
+						retryDelay = s.RetryDelay
+					} else if retryDelay *= 2; retryDelay > s.IdleTimeout {
+						retryDelay = s.IdleTimeout
+					}
+					s.api.logger.Warn("failed to stop idle server", "retry", retryDelay, "error", err)
+
+					// Keep counting down without repeating the warnings.
+					deadline, warned = now.Add(retryDelay), len(warnings)
+					wake = time.After(retryDelay)
+				}
+			} else {
+				next := deadline
+				if warned < len(warnings) {
+					next = deadline.Add(-warnings[warned])
+				}
+				wake = time.After(next.Sub(now))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wss.receiverDone:
+			return errWebsocketClosed
+		case <-s.updates:
+		case <-wake:
+		}
+	}
+}
+
+// command sends a console command and logs failures.
+func (s *IdleStopper) command(command string) {
+	if err := s.wss.SendCommand(command); err != nil {
+		s.api.logger.Warn("failed to send console command", "command", command, "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package aternos_api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// newTestIdleStopper returns an IdleStopper that controls the server of given fake, which is expected to be empty.
+func newTestIdleStopper(f *fakeAternos) *IdleStopper {
+	stopper := NewIdleStopper(f.newApi(), f.newWebsocket())
+	stopper.IdleTimeout = 100 * time.Millisecond
+	stopper.Warnings = []time.Duration{time.Minute, 50 * time.Millisecond}
+	stopper.Warning = func(remaining time.Duration) string {
+		return "say stopping in " + remaining.String()
+	}
+	stopper.Cancelled = "say cancelled"
+	return stopper
+}
+
+func TestIdleStopper_Run(t *testing.T) {
+	f := newFakeAternos(t, `{"status":1,"players":0}`)
+	stopper := newTestIdleStopper(f)
+
+	stopped := make(chan error, 1)
+	stopper.OnStop = func(err error) {
+		stopped <- err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stopper.Run(ctx)
+
+	// The warning that's longer than the idle timeout is skipped.
+	if command := f.command(); command != "say stopping in 50ms" {
+		t.Fatalf("unexpected command %q", command)
+	}
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server wasn't stopped")
+	}
+
+	if n := f.count("stop"); n != 1 {
+		t.Fatalf("expected 1 stop request, got %d", n)
+	}
+}
+
+func TestIdleStopper_Run_cancelled(t *testing.T) {
+	f := newFakeAternos(t, `{"status":1,"players":0}`)
+	stopper := newTestIdleStopper(f)
+	stopper.IdleTimeout = 300 * time.Millisecond
+	stopper.Warnings = []time.Duration{250 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stopper.Run(ctx)
+
+	f.command()
+
+	info, _ := json.Marshal(ServerInfo{Status: Online, Players: 1, PlayerList: []string{"Steve"}})
+	stopper.HandleMessage(WebsocketMessage{Type: "status", MessageBytes: info})
+
+	if command := f.command(); command != "say cancelled" {
+		t.Fatalf("unexpected command %q", command)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if n := f.count("stop"); n != 0 {
+		t.Fatalf("expected no stop requests, got %d", n)
+	}
+}
+
+func TestIdleStopper_Run_retry(t *testing.T) {
+	f := newFakeAternos(t, `{"status":1,"players":0}`)
+	f.fail("stop", 1)
+
+	stopper := newTestIdleStopper(f)
+	stopper.Warnings = nil
+	stopper.RetryDelay = 50 * time.Millisecond
+
+	stopped := make(chan error, 2)
+	stopper.OnStop = func(err error) {
+		stopped <- err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stopper.Run(ctx)
+
+	for i, failed := range []bool{true, false} {
+		select {
+		case err := <-stopped:
+			if (err != nil) != failed {
+				t.Fatalf("attempt %d: unexpected error %v", i+1, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("attempt %d: server wasn't stopped", i+1)
+		}
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if n := f.count("stop"); n != 2 {
+		t.Fatalf("expected 2 stop requests, got %d", n)
+	}
+}
+
+func TestIdleStopper_Run_closed(t *testing.T) {
+	stopper := newTestIdleStopper(newFakeAternos(t, `{"status":1,"players":0}`))
+	stopper.IdleTimeout = time.Minute
+
+	done := make(chan error, 1)
+	go func() {
+		done <- stopper.Run(context.Background())
+	}()
+
+	stopper.wss.Close()
+
+	select {
+	case err := <-done:
+		if err != errWebsocketClosed {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return")
+	}
+}
@@ -0,0 +1,278 @@
+// Package discord connects an Aternos server to a Discord bot.
+//
+// The bot responds to the slash commands /start, /stop, /status and /players,
+// and optionally keeps a status embed up-to-date in a channel.
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"strings"
+	"sync"
+)
+
+// Commands are the slash commands the bot responds to.
+var Commands = []Command{
+	{Name: "start", Description: "Start the Minecraft server"},
+	{Name: "stop", Description: "Stop the Minecraft server"},
+	{Name: "status", Description: "Show the status of the Minecraft server"},
+	{Name: "players", Description: "List the players that are online"},
+}
+
+// Server is the part of aternos.Api that the bot uses.
+type Server interface {
+	GetServerInfo() (aternos.ServerInfo, error)
+	StartServer() error
+	StopServer() error
+	WatchStatus(ctx context.Context) <-chan aternos.StatusChange
+}
+
+// Bot responds to slash commands and posts status updates.
+type Bot struct {
+	// Channel in which a status embed is posted and kept up-to-date.
+	// Empty means no status embed is posted.
+	StatusChannel string
+
+	// Called when handling a command or updating the status embed fails.
+	OnError func(err error)
+
+	server  Server
+	gateway Gateway
+
+	// confirm keeps confirming the server until it leaves the queue.
+	confirm func(ctx context.Context) error
+
+	queue *aternos.QueueTracker
+
+	mu              sync.Mutex
+	info            *aternos.ServerInfo
+	statusMessageId string
+	confirming      bool
+}
+
+// New allocates a new Bot that controls the server of given api.
+// Once started, the server is confirmed automatically when it's its turn in queue.
+func New(api *aternos.Api, gateway Gateway) *Bot {
+	return newBot(api, gateway, func(ctx context.Context) error {
+		return aternos.NewAutoConfirmer(api).Run(ctx)
+	})
+}
+
+func newBot(server Server, gateway Gateway, confirm func(ctx context.Context) error) *Bot {
+	return &Bot{
+		server:  server,
+		gateway: gateway,
+		confirm: confirm,
+		queue:   aternos.NewQueueTracker(),
+	}
+}
+
+// Run registers the slash commands and handles interactions and status changes until ctx is done
+// or the gateway closes the interactions channel.
+func (b *Bot) Run(ctx context.Context) error {
+	if err := b.gateway.RegisterCommands(ctx, Commands); err != nil {
+		return fmt.Errorf("failed to register commands: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Background work, such as confirming the server, is cancelled before it's awaited.
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	changes := b.server.WatchStatus(ctx)
+	interactions := b.gateway.Interactions()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+			b.observe(change.Info)
+			if err := b.updateStatusMessage(ctx); err != nil {
+				b.error(err)
+			}
+		case interaction, ok := <-interactions:
+			if !ok {
+				return nil
+			}
+			// Handled concurrently, so a slow response doesn't delay other interactions.
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := b.handle(ctx, interaction, &wg); err != nil {
+					b.error(fmt.Errorf("failed to handle /%s: %w", interaction.Command, err))
+				}
+			}()
+		}
+	}
+}
+
+func (b *Bot) observe(info aternos.ServerInfo) {
+	b.queue.Observe(info)
+
+	b.mu.Lock()
+	b.info = &info
+	b.mu.Unlock()
+}
+
+func (b *Bot) error(err error) {
+	if b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+// handle responds to an interaction.
+// Background work that outlives the interaction is added to wg.
+func (b *Bot) handle(ctx context.Context, interaction Interaction, wg *sync.WaitGroup) error {
+	if err := b.gateway.Defer(ctx, interaction); err != nil {
+		return err
+	}
+
+	var response Response
+	switch interaction.Command {
+	case "start":
+		response = b.start(ctx, wg)
+	case "stop":
+		response = b.stop()
+	case "status":
+		response = b.status()
+	case "players":
+		response = b.players()
+	default:
+		response = Response{Content: fmt.Sprintf("Unknown command /%s.", interaction.Command), Ephemeral: true}
+	}
+
+	return b.gateway.Respond(ctx, interaction, response)
+}
+
+func (b *Bot) start(ctx context.Context, wg *sync.WaitGroup) Response {
+	err := b.server.StartServer()
+	if err != nil && !errors.Is(err, aternos.ServerInQueueError) {
+		return errorResponse(err)
+	}
+
+	b.mu.Lock()
+	confirming := b.confirming
+	b.confirming = true
+	b.mu.Unlock()
+
+	if !confirming {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				b.mu.Lock()
+				b.confirming = false
+				b.mu.Unlock()
+			}()
+			if err := b.confirm(ctx); err != nil && ctx.Err() == nil {
+				b.error(fmt.Errorf("failed to confirm server: %w", err))
+			}
+		}()
+	}
+
+	if err != nil {
+		return Response{Content: "The server is already waiting in queue, it will be confirmed when it's its turn."}
+	}
+
+	return Response{Content: "Starting the server, it will be confirmed when it's its turn in queue."}
+}
+
+func (b *Bot) stop() Response {
+	if err := b.server.StopServer(); err != nil {
+		return errorResponse(err)
+	}
+	return Response{Content: "Stopping the server."}
+}
+
+func (b *Bot) status() Response {
+	info, err := b.server.GetServerInfo()
+	if err != nil {
+		return errorResponse(err)
+	}
+	b.observe(info)
+
+	return Response{Embeds: []Embed{statusEmbed(info, b.queue.Estimate())}}
+}
+
+func (b *Bot) players() Response {
+	info, err := b.server.GetServerInfo()
+	if err != nil {
+		return errorResponse(err)
+	}
+	b.observe(info)
+
+	if info.Status != aternos.Online {
+		return Response{Content: fmt.Sprintf("The server is %s.", info.Status)}
+	}
+	if len(info.PlayerList) == 0 {
+		return Response{Content: fmt.Sprintf("Nobody is online (0/%d).", info.MaxPlayers)}
+	}
+
+	return Response{Content: fmt.Sprintf("Online (%d/%d): %s", info.Players, info.MaxPlayers, strings.Join(info.PlayerList, ", "))}
+}
+
+// updateStatusMessage posts the status embed, or edits it if it has been posted before.
+func (b *Bot) updateStatusMessage(ctx context.Context) error {
+	if b.StatusChannel == "" {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.info == nil {
+		return nil
+	}
+
+	response := Response{Embeds: []Embed{statusEmbed(*b.info, b.queue.Estimate())}}
+
+	if b.statusMessageId != "" {
+		if err := b.gateway.EditMessage(ctx, b.StatusChannel, b.statusMessageId, response); err != nil {
+			return fmt.Errorf("failed to edit status message: %w", err)
+		}
+		return nil
+	}
+
+	id, err := b.gateway.SendMessage(ctx, b.StatusChannel, response)
+	if err != nil {
+		return fmt.Errorf("failed to send status message: %w", err)
+	}
+	b.statusMessageId = id
+
+	return nil
+}
+
+// errorResponse describes err to the user that invoked a command.
+func errorResponse(err error) Response {
+	var content string
+
+	switch {
+	case errors.Is(err, aternos.ServerAlreadyStartedError):
+		content = "The server is already running."
+	case errors.Is(err, aternos.ServerAlreadyStoppedError):
+		content = "The server is already stopped."
+	case errors.Is(err, aternos.EulaNotAcceptedError):
+		content = "The Minecraft EULA must be accepted first."
+	case errors.Is(err, aternos.WrongVersionError):
+		content = "The installed software version is incorrect."
+	case errors.Is(err, aternos.StorageLimitError):
+		content = "The storage limit of the server has been reached."
+	case errors.Is(err, aternos.FileServerUnavailableError):
+		content = "The Aternos file server is unavailable, see https://status.aternos.gmbh."
+	default:
+		content = fmt.Sprintf("Something went wrong: %s", err)
+	}
+
+	return Response{Content: content, Ephemeral: true}
+}
@@ -0,0 +1,253 @@
+package discord
+
+import (
+	"context"
+	aternos "github.com/sleeyax/aternos-api"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeGateway struct {
+	interactions chan Interaction
+	responses    chan Response
+
+	mu       sync.Mutex
+	commands []Command
+	messages map[string]Response
+	sent     int
+}
+
+func newFakeGateway() *fakeGateway {
+	return &fakeGateway{
+		interactions: make(chan Interaction),
+		responses:    make(chan Response, 10),
+		messages:     map[string]Response{},
+	}
+}
+
+func (g *fakeGateway) RegisterCommands(ctx context.Context, commands []Command) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.commands = commands
+	return nil
+}
+
+func (g *fakeGateway) Interactions() <-chan Interaction {
+	return g.interactions
+}
+
+func (g *fakeGateway) Defer(ctx context.Context, interaction Interaction) error {
+	return nil
+}
+
+func (g *fakeGateway) Respond(ctx context.Context, interaction Interaction, response Response) error {
+	g.responses <- response
+	return nil
+}
+
+func (g *fakeGateway) SendMessage(ctx context.Context, channelID string, response Response) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sent++
+	id := channelID + "/status"
+	g.messages[id] = response
+	return id, nil
+}
+
+func (g *fakeGateway) EditMessage(ctx context.Context, channelID string, messageID string, response Response) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.messages[messageID] = response
+	return nil
+}
+
+// invoke invokes a slash command and waits for the response.
+func (g *fakeGateway) invoke(t *testing.T, command string) Response {
+	t.Helper()
+	g.interactions <- Interaction{ID: command, Command: command}
+	select {
+	case response := <-g.responses:
+		return response
+	case <-time.After(time.Second):
+		t.Fatalf("no response to /%s", command)
+		return Response{}
+	}
+}
+
+type fakeServer struct {
+	mu       sync.Mutex
+	info     aternos.ServerInfo
+	starts   int
+	startErr error
+	changes  chan aternos.StatusChange
+}
+
+func (s *fakeServer) GetServerInfo() (aternos.ServerInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.info, nil
+}
+
+func (s *fakeServer) StartServer() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starts++
+	return s.startErr
+}
+
+func (s *fakeServer) StopServer() error {
+	return aternos.ServerAlreadyStoppedError
+}
+
+func (s *fakeServer) WatchStatus(ctx context.Context) <-chan aternos.StatusChange {
+	return s.changes
+}
+
+func runBot(t *testing.T, bot *Bot) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- bot.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestBot_commands(t *testing.T) {
+	server := &fakeServer{changes: make(chan aternos.StatusChange)}
+	server.info = aternos.ServerInfo{
+		Name:       "test",
+		Status:     aternos.Online,
+		Address:    "test.aternos.me",
+		DynIP:      "1.2.3.4:25565",
+		Port:       25565,
+		Players:    2,
+		MaxPlayers: 20,
+		PlayerList: []string{"alice", "bob"},
+	}
+	gateway := newFakeGateway()
+
+	confirmed := make(chan struct{}, 10)
+	bot := newBot(server, gateway, func(ctx context.Context) error {
+		confirmed <- struct{}{}
+		return nil
+	})
+	runBot(t, bot)
+
+	response := gateway.invoke(t, "status")
+	if len(response.Embeds) != 1 {
+		t.Fatalf("expected a status embed, got %+v", response)
+	}
+	fields := map[string]string{}
+	for _, field := range response.Embeds[0].Fields {
+		fields[field.Name] = field.Value
+	}
+	expected := map[string]string{
+		"Address": "test.aternos.me",
+		"Dyn IP":  "1.2.3.4:25565",
+		"Port":    "25565",
+		"Players": "2/20: alice, bob",
+	}
+	for name, value := range expected {
+		if fields[name] != value {
+			t.Errorf("expected field %s to be %q, got %q", name, value, fields[name])
+		}
+	}
+
+	if response = gateway.invoke(t, "players"); !strings.Contains(response.Content, "alice, bob") {
+		t.Fatalf("expected players to be listed, got %q", response.Content)
+	}
+
+	if response = gateway.invoke(t, "stop"); !response.Ephemeral || response.Content != "The server is already stopped." {
+		t.Fatalf("expected an ephemeral error, got %+v", response)
+	}
+
+	gateway.invoke(t, "start")
+	select {
+	case <-confirmed:
+	case <-time.After(time.Second):
+		t.Fatal("expected server to be confirmed after /start")
+	}
+	if server.starts != 1 {
+		t.Fatalf("expected server to be started once, got %d", server.starts)
+	}
+
+	server.mu.Lock()
+	server.startErr = aternos.EulaNotAcceptedError
+	server.mu.Unlock()
+	if response = gateway.invoke(t, "start"); !response.Ephemeral || response.Content != "The Minecraft EULA must be accepted first." {
+		t.Fatalf("expected an ephemeral EULA error, got %+v", response)
+	}
+
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+	if len(gateway.commands) != len(Commands) {
+		t.Fatalf("expected %d commands to be registered, got %d", len(Commands), len(gateway.commands))
+	}
+}
+
+func TestBot_statusMessage(t *testing.T) {
+	server := &fakeServer{changes: make(chan aternos.StatusChange)}
+	gateway := newFakeGateway()
+
+	bot := newBot(server, gateway, func(ctx context.Context) error { return nil })
+	bot.StatusChannel = "channel"
+	runBot(t, bot)
+
+	queued := aternos.ServerInfo{Status: aternos.Preparing}
+	queued.Queue = aternos.Queue{Position: 3, Count: 10, Minutes: 2, Status: aternos.QueueWaiting}
+
+	server.changes <- aternos.StatusChange{From: aternos.Offline, To: aternos.Offline, Info: aternos.ServerInfo{Status: aternos.Offline}}
+	server.changes <- aternos.StatusChange{From: aternos.Offline, To: aternos.Preparing, Info: queued}
+	// Sent after the previous change has been processed, because the channel is unbuffered.
+	server.changes <- aternos.StatusChange{From: aternos.Preparing, To: aternos.Preparing, Info: queued}
+
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	if gateway.sent != 1 {
+		t.Fatalf("expected status message to be sent once and edited afterwards, sent %d", gateway.sent)
+	}
+
+	embed := gateway.messages["channel/status"].Embeds[0]
+	if embed.Color != colorQueue {
+		t.Fatalf("expected queue color, got %x", embed.Color)
+	}
+	var queue string
+	for _, field := range embed.Fields {
+		if field.Name == "Queue" {
+			queue = field.Value
+		}
+	}
+	if queue != "3/10, ETA 2m0s" {
+		t.Fatalf("unexpected queue field %q", queue)
+	}
+}
+
+func TestBot_Run_cancelsConfirmer(t *testing.T) {
+	server := &fakeServer{changes: make(chan aternos.StatusChange)}
+	gateway := newFakeGateway()
+
+	// Confirms until it's cancelled, like AutoConfirmer while the server stays offline.
+	bot := newBot(server, gateway, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	done := make(chan error)
+	go func() { done <- bot.Run(context.Background()) }()
+
+	gateway.invoke(t, "start")
+	close(gateway.interactions)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to return once the interactions channel is closed")
+	}
+}
@@ -0,0 +1,77 @@
+package discord
+
+import (
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"strings"
+	"time"
+)
+
+// Embed colors by status.
+const (
+	colorOnline     = 0x2ecc71
+	colorOffline    = 0xe74c3c
+	colorTransition = 0xf1c40f
+	colorQueue      = 0x3498db
+)
+
+// statusEmbed returns an embed that describes the server.
+// The queue estimate is only used while the server is preparing.
+func statusEmbed(info aternos.ServerInfo, queue aternos.QueueEstimate) Embed {
+	embed := Embed{
+		Title:       info.Name,
+		Description: fmt.Sprintf("Server is **%s**", info.Status),
+		Color:       statusColor(info.Status),
+		Footer:      "Last updated " + time.Now().UTC().Format("2006-01-02 15:04 MST"),
+	}
+
+	if embed.Title == "" {
+		embed.Title = "Minecraft server"
+	}
+
+	address := info.Address
+	if address == "" {
+		address = "unknown"
+	}
+	embed.Fields = append(embed.Fields, EmbedField{Name: "Address", Value: address, Inline: true})
+
+	if info.Status == aternos.Online {
+		if info.DynIP != "" {
+			embed.Fields = append(embed.Fields, EmbedField{Name: "Dyn IP", Value: info.DynIP, Inline: true})
+		}
+		embed.Fields = append(embed.Fields, EmbedField{Name: "Port", Value: fmt.Sprint(info.Port), Inline: true})
+		embed.Fields = append(embed.Fields, EmbedField{Name: "Players", Value: playersValue(info), Inline: false})
+	}
+
+	if info.Status == aternos.Preparing {
+		value := fmt.Sprintf("%d/%d", queue.Position, queue.Count)
+		if queue.ETA > 0 {
+			value += fmt.Sprintf(", ETA %s", queue.ETA.Round(time.Second))
+		}
+		embed.Fields = append(embed.Fields, EmbedField{Name: "Queue", Value: value, Inline: true})
+	}
+
+	return embed
+}
+
+// playersValue lists the players that are online.
+func playersValue(info aternos.ServerInfo) string {
+	value := fmt.Sprintf("%d/%d", info.Players, info.MaxPlayers)
+	if len(info.PlayerList) > 0 {
+		value += ": " + strings.Join(info.PlayerList, ", ")
+	}
+	return value
+}
+
+func statusColor(status aternos.ServerStatus) int {
+	switch {
+	case status == aternos.Online:
+		return colorOnline
+	case status == aternos.Preparing:
+		return colorQueue
+	case status.IsStopped():
+		return colorOffline
+	default:
+		return colorTransition
+	}
+}
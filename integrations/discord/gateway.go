@@ -0,0 +1,78 @@
+package discord
+
+import "context"
+
+// Gateway is the connection to Discord.
+//
+// This package doesn't depend on a Discord library, implement Gateway with the library of your choice,
+// e.g. github.com/bwmarrin/discordgo.
+type Gateway interface {
+	// RegisterCommands registers the slash commands of the bot.
+	RegisterCommands(ctx context.Context, commands []Command) error
+
+	// Interactions returns the channel on which slash command invocations are received.
+	// It's closed when the connection to Discord is closed.
+	Interactions() <-chan Interaction
+
+	// Defer acknowledges an interaction of which the response takes a while.
+	// Discord requires interactions to be acknowledged within 3 seconds.
+	Defer(ctx context.Context, interaction Interaction) error
+
+	// Respond responds to an interaction.
+	// It edits the original response if the interaction was deferred.
+	Respond(ctx context.Context, interaction Interaction, response Response) error
+
+	// SendMessage posts a message in a channel and returns its ID.
+	SendMessage(ctx context.Context, channelID string, response Response) (messageID string, err error)
+
+	// EditMessage replaces the content of a message that was posted with SendMessage.
+	EditMessage(ctx context.Context, channelID string, messageID string, response Response) error
+}
+
+// Command is a slash command definition.
+type Command struct {
+	Name        string
+	Description string
+}
+
+// Interaction is an invocation of a slash command.
+type Interaction struct {
+	// Interaction ID, used by the gateway to respond.
+	ID string
+
+	// Name of the command, e.g. "start".
+	Command string
+
+	// Channel in which the command was invoked.
+	ChannelID string
+
+	// User that invoked the command.
+	UserID string
+}
+
+// Response is the content of a message.
+type Response struct {
+	Content string
+	Embeds  []Embed
+
+	// Whether only the user that invoked the command can see the response.
+	Ephemeral bool
+}
+
+// Embed is a rich message.
+type Embed struct {
+	Title       string
+	Description string
+
+	// RGB color of the left border.
+	Color  int
+	Fields []EmbedField
+	Footer string
+}
+
+// EmbedField is a field of an Embed.
+type EmbedField struct {
+	Name   string
+	Value  string
+	Inline bool
+}
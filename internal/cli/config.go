@@ -0,0 +1,221 @@
+// Package cli holds what the aternos command and the daemons next to it share:
+// reading credentials from flags, the environment or a config file, and following a websocket connection.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// UsageError indicates invalid usage of a command, such as missing credentials.
+type UsageError struct {
+	msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.msg
+}
+
+// UsageErrorf formats a UsageError.
+func UsageErrorf(format string, args ...interface{}) error {
+	return &UsageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Config holds the credentials and connection settings.
+//
+// Each setting is read from (in order of precedence) a command line flag, an environment variable or the config file.
+type Config struct {
+	// ATERNOS_SESSION cookie.
+	Session string `json:"session,omitempty"`
+
+	// ATERNOS_SERVER cookie.
+	Server string `json:"server,omitempty"`
+
+	// ATERNOS_LANGUAGE cookie.
+	Language string `json:"language,omitempty"`
+
+	// Optional proxy to connect through.
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// Environment variables that hold the settings.
+const (
+	EnvConfig   = "ATERNOS_CONFIG"
+	EnvSession  = "ATERNOS_SESSION"
+	EnvServer   = "ATERNOS_SERVER"
+	EnvLanguage = "ATERNOS_LANGUAGE"
+	EnvProxy    = "ATERNOS_PROXY"
+)
+
+// DefaultConfigPath returns the default location of the config file,
+// e.g. ~/.config/aternos/config.json on Linux.
+func DefaultConfigPath() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "aternos.json"
+	}
+	return filepath.Join(dir, "aternos", "config.json")
+}
+
+// LoadConfig reads the config file at given path.
+// A missing file results in an empty config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if err = json.Unmarshal(data, &c); err != nil {
+		return c, UsageErrorf("invalid config file %s: %s", path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the config file to given path, creating its directory if needed.
+// The file is only readable by the current user, since it contains credentials.
+func (c Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Merge overrides the settings of c with the non-empty settings of other.
+func (c Config) Merge(other Config) Config {
+	if other.Session != "" {
+		c.Session = other.Session
+	}
+	if other.Server != "" {
+		c.Server = other.Server
+	}
+	if other.Language != "" {
+		c.Language = other.Language
+	}
+	if other.Proxy != "" {
+		c.Proxy = other.Proxy
+	}
+	return c
+}
+
+// ConfigFromEnv returns the settings of the environment variables.
+func ConfigFromEnv() Config {
+	return Config{
+		Session:  os.Getenv(EnvSession),
+		Server:   os.Getenv(EnvServer),
+		Language: os.Getenv(EnvLanguage),
+		Proxy:    os.Getenv(EnvProxy),
+	}
+}
+
+// Cookies returns the authentication cookies of the config.
+func (c Config) Cookies() []*http.Cookie {
+	return []*http.Cookie{
+		{
+			Name:  "ATERNOS_LANGUAGE",
+			Value: c.Language,
+		},
+		{
+			Name:  "ATERNOS_SESSION",
+			Value: c.Session,
+		},
+		{
+			Name:  "ATERNOS_SERVER",
+			Value: c.Server,
+		},
+	}
+}
+
+// Flags are the flags that select the config file and override its settings.
+//
+// Prefer the config file or environment variables for the cookies,
+// since command line arguments are visible to other users, e.g. through ps.
+type Flags struct {
+	Config   *string
+	Session  *string
+	Server   *string
+	Lang     *string
+	Proxy    *string
+	Insecure *bool
+}
+
+// AddFlags defines the flags on given flag set.
+func AddFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		Config:   fs.String("config", DefaultConfigPath(), "config file (env "+EnvConfig+")"),
+		Session:  fs.String("session", "", "ATERNOS_SESSION cookie (env "+EnvSession+")"),
+		Server:   fs.String("server", "", "ATERNOS_SERVER cookie (env "+EnvServer+")"),
+		Lang:     fs.String("lang", "", "ATERNOS_LANGUAGE cookie (env "+EnvLanguage+", default en)"),
+		Proxy:    fs.String("proxy", "", "optional proxy to connect to (env "+EnvProxy+")"),
+		Insecure: fs.Bool("insecure", false, "skip TLS certificate verification, e.g. for debugging proxies"),
+	}
+}
+
+// Resolve combines the config file, environment variables and flags.
+func (f *Flags) Resolve() (Config, error) {
+	c, err := LoadConfig(*f.Config)
+	if err != nil {
+		return c, err
+	}
+
+	c = c.Merge(ConfigFromEnv()).Merge(Config{
+		Session:  *f.Session,
+		Server:   *f.Server,
+		Language: *f.Lang,
+		Proxy:    *f.Proxy,
+	})
+
+	if c.Language == "" {
+		c.Language = "en"
+	}
+
+	return c, nil
+}
+
+// NewApi creates a new api instance with all required authentication cookies set.
+func (f *Flags) NewApi() (*aternos.Api, error) {
+	c, err := f.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Session == "" || c.Server == "" {
+		return nil, UsageErrorf("missing credentials: specify the session and server cookies with the %s and %s environment variables, the config file %s (see 'aternos cookies import') or -session and -server", EnvSession, EnvServer, *f.Config)
+	}
+
+	// Parse proxy (if specified).
+	var p *url.URL
+	if c.Proxy != "" {
+		if p, err = url.Parse(c.Proxy); err != nil {
+			return nil, UsageErrorf("invalid proxy: %s", err)
+		}
+	}
+
+	return aternos.New(&aternos.Options{
+		Cookies:            c.Cookies(),
+		Proxy:              p,
+		InsecureSkipVerify: *f.Insecure,
+	}), nil
+}
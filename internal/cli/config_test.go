@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlags_Resolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := (Config{Session: "file-session", Server: "file-server", Proxy: "http://file"}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvServer, "env-server")
+	t.Setenv(EnvProxy, "http://env")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := AddFlags(fs)
+	if err := fs.Parse([]string{"-config", path, "-proxy", "http://flag"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := flags.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{Session: "file-session", Server: "env-server", Language: "en", Proxy: "http://flag"}
+	if c != want {
+		t.Fatalf("expected %+v, got %+v", want, c)
+	}
+}
+
+func TestFlags_NewApi_missingCredentials(t *testing.T) {
+	t.Setenv(EnvSession, "")
+	t.Setenv(EnvServer, "")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := AddFlags(fs)
+	if err := fs.Parse([]string{"-config", filepath.Join(t.TempDir(), "config.json")}); err != nil {
+		t.Fatal(err)
+	}
+
+	var usageErr *UsageError
+	if _, err := flags.NewApi(); !errors.As(err, &usageErr) {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ReconnectDelay is the time to wait before reconnecting after a websocket connection failed.
+const ReconnectDelay = 30 * time.Second
+
+// Reconnect calls follow until ctx is done, waiting ReconnectDelay between calls.
+// follow is expected to observe a single websocket connection until it drops or ctx is done.
+func Reconnect(ctx context.Context, follow func(ctx context.Context) error) {
+	for ctx.Err() == nil {
+		if err := follow(ctx); err != nil {
+			LogReconnect(err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(ReconnectDelay):
+		}
+	}
+}
+
+// LogReconnect logs that the websocket connection failed with given error and is reconnected after ReconnectDelay.
+func LogReconnect(err error) {
+	log.Printf("Websocket connection failed, reconnecting in %s: %s\n", ReconnectDelay, err)
+}
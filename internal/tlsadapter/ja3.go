@@ -0,0 +1,146 @@
+package tlsadapter
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// errMalformedClientHello is returned when a ClientHello message can't be parsed.
+var errMalformedClientHello = errors.New("malformed ClientHello")
+
+// JA3 computes the JA3 fingerprint string of given raw ClientHello handshake message.
+// GREASE values are excluded, as described in https://github.com/salesforce/ja3.
+//
+// The returned string is usually hashed with MD5 to obtain the JA3 hash.
+func JA3(raw []byte) (string, error) {
+	r := reader(raw)
+
+	// Handshake message type (1) and length (3).
+	if _, ok := r.next(4); !ok {
+		return "", errMalformedClientHello
+	}
+
+	version, ok := r.uint16()
+	if !ok {
+		return "", errMalformedClientHello
+	}
+
+	// Random.
+	if _, ok = r.next(32); !ok {
+		return "", errMalformedClientHello
+	}
+
+	// Session ID.
+	if _, ok = r.vector8(); !ok {
+		return "", errMalformedClientHello
+	}
+
+	cipherSuites, ok := r.vector16()
+	if !ok {
+		return "", errMalformedClientHello
+	}
+
+	// Compression methods.
+	if _, ok = r.vector8(); !ok {
+		return "", errMalformedClientHello
+	}
+
+	var extensions, curves, pointFormats []uint16
+
+	if exts, ok := r.vector16(); ok {
+		for len(exts) > 0 {
+			id, _ := exts.uint16()
+			data, ok := exts.vector16()
+			if !ok {
+				return "", errMalformedClientHello
+			}
+
+			if isGREASE(id) {
+				continue
+			}
+			extensions = append(extensions, id)
+
+			switch id {
+			case 10: // supported_groups
+				list, _ := data.vector16()
+				curves = list.uint16s()
+			case 11: // ec_point_formats
+				list, _ := data.vector8()
+				for _, f := range list {
+					pointFormats = append(pointFormats, uint16(f))
+				}
+			}
+		}
+	}
+
+	return strings.Join([]string{
+		strconv.Itoa(int(version)),
+		join(cipherSuites.uint16s()),
+		join(extensions),
+		join(curves),
+		join(pointFormats),
+	}, ","), nil
+}
+
+// isGREASE reports whether v is a GREASE value (RFC 8701).
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// join joins the values that aren't GREASE values with a dash.
+func join(values []uint16) string {
+	var s []string
+	for _, v := range values {
+		if !isGREASE(v) {
+			s = append(s, strconv.Itoa(int(v)))
+		}
+	}
+	return strings.Join(s, "-")
+}
+
+// reader reads length-prefixed TLS vectors.
+type reader []byte
+
+func (r *reader) next(n int) (reader, bool) {
+	if len(*r) < n {
+		return nil, false
+	}
+	v := (*r)[:n]
+	*r = (*r)[n:]
+	return v, true
+}
+
+func (r *reader) uint16() (uint16, bool) {
+	v, ok := r.next(2)
+	if !ok {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(v), true
+}
+
+func (r *reader) vector8() (reader, bool) {
+	n, ok := r.next(1)
+	if !ok {
+		return nil, false
+	}
+	return r.next(int(n[0]))
+}
+
+func (r *reader) vector16() (reader, bool) {
+	n, ok := r.uint16()
+	if !ok {
+		return nil, false
+	}
+	return r.next(int(n))
+}
+
+func (r reader) uint16s() []uint16 {
+	var values []uint16
+	for len(r) >= 2 {
+		v, _ := r.uint16()
+		values = append(values, v)
+	}
+	return values
+}
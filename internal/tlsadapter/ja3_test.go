@@ -0,0 +1,50 @@
+package tlsadapter
+
+import (
+	utls "github.com/refraction-networking/utls"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestJA3(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	uconn := utls.UClient(client, &utls.Config{ServerName: "aternos.org"}, utls.HelloCustom)
+	if err := uconn.ApplyPreset(GetCustomClientHelloSpec()); err != nil {
+		t.Fatal(err)
+	}
+	if err := uconn.BuildHandshakeState(); err != nil {
+		t.Fatal(err)
+	}
+
+	ja3, err := JA3(uconn.HandshakeState.Hello.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := strings.Split(ja3, ",")
+	if len(fields) != 5 {
+		t.Fatalf("expected 5 fields, got %q", ja3)
+	}
+	if fields[0] != "771" {
+		t.Errorf("expected TLS 1.2 legacy version, got %s", fields[0])
+	}
+	if !strings.HasPrefix(fields[1], "4865-4866-4867-49195") {
+		t.Errorf("unexpected cipher suites %s", fields[1])
+	}
+	if fields[3] != "29-23-24" {
+		t.Errorf("unexpected curves %s", fields[3])
+	}
+	if fields[4] != "0" {
+		t.Errorf("unexpected point formats %s", fields[4])
+	}
+}
+
+func TestJA3_malformed(t *testing.T) {
+	if _, err := JA3([]byte{1, 0, 0}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -17,6 +17,30 @@ type TLSAdapter struct {
 
 	// Optional TLS configuration to use.
 	Config *utls.Config
+
+	// Optional function that is called after each successful TLS handshake.
+	OnHandshake func(info HandshakeInfo)
+}
+
+// HandshakeInfo describes a completed TLS handshake.
+type HandshakeInfo struct {
+	// Name of the ClientHelloID that was used.
+	Fingerprint string
+
+	// JA3 fingerprint string of the ClientHello that was sent.
+	JA3 string
+
+	// Negotiated TLS version.
+	Version uint16
+
+	// Negotiated cipher suite.
+	CipherSuite uint16
+
+	// Negotiated application protocol (ALPN).
+	NegotiatedProtocol string
+
+	// Server name that was sent (SNI).
+	ServerName string
 }
 
 // New creates a new gotcha adapter configured with a Chrome 96 browser TLS fingerprint.
@@ -72,5 +96,20 @@ func (ua *TLSAdapter) ConnectTLSContext(_ context.Context, conn net.Conn) (net.C
 		return nil, err
 	}
 
+	if ua.OnHandshake != nil {
+		state := uconn.ConnectionState()
+		info := HandshakeInfo{
+			Fingerprint:        ua.Fingerprint.Str(),
+			Version:            state.Version,
+			CipherSuite:        state.CipherSuite,
+			NegotiatedProtocol: state.NegotiatedProtocol,
+			ServerName:         config.ServerName,
+		}
+		if hello := uconn.HandshakeState.Hello; hello != nil {
+			info.JA3, _ = JA3(hello.Raw)
+		}
+		ua.OnHandshake(info)
+	}
+
 	return uconn, nil
 }
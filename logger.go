@@ -0,0 +1,52 @@
+package aternos_api
+
+// Logger is a leveled, structured logger.
+// Args are alternating keys and values that add context to the message, such as "endpoint", "ajax/server/start".
+//
+// It's implemented by *slog.Logger from the standard log/slog package, so you can pass e.g. slog.Default().
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// nopLogger is a Logger that discards all messages.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// fieldLogger is a Logger that adds a fixed set of fields to every message.
+type fieldLogger struct {
+	logger Logger
+	fields []interface{}
+}
+
+// withFields returns a Logger that adds given key-value pairs to every message logged by logger.
+func withFields(logger Logger, fields ...interface{}) Logger {
+	if logger == nil {
+		return nopLogger{}
+	}
+
+	if _, ok := logger.(nopLogger); ok {
+		return logger
+	}
+
+	if fl, ok := logger.(*fieldLogger); ok {
+		return &fieldLogger{logger: fl.logger, fields: append(fl.fields[:len(fl.fields):len(fl.fields)], fields...)}
+	}
+
+	return &fieldLogger{logger: logger, fields: fields}
+}
+
+func (l *fieldLogger) args(args []interface{}) []interface{} {
+	return append(l.fields[:len(l.fields):len(l.fields)], args...)
+}
+
+func (l *fieldLogger) Debug(msg string, args ...interface{}) { l.logger.Debug(msg, l.args(args)...) }
+func (l *fieldLogger) Info(msg string, args ...interface{})  { l.logger.Info(msg, l.args(args)...) }
+func (l *fieldLogger) Warn(msg string, args ...interface{})  { l.logger.Warn(msg, l.args(args)...) }
+func (l *fieldLogger) Error(msg string, args ...interface{}) { l.logger.Error(msg, l.args(args)...) }
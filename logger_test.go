@@ -0,0 +1,54 @@
+package aternos_api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// recordingLogger is a Logger that records all messages.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	l.messages = append(l.messages, fmt.Sprint(append([]interface{}{level, msg}, args...)...))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG ", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("INFO ", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("WARN ", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("ERROR ", msg, args) }
+
+func TestWithFields(t *testing.T) {
+	if _, ok := withFields(nil, "server", "abc").(nopLogger); !ok {
+		t.Fatal("expected nil logger to be silent")
+	}
+
+	recorder := &recordingLogger{}
+	logger := withFields(withFields(recorder, "server", "abc"), "stream", "console")
+	logger.Info("hello", "type", "line")
+
+	if len(recorder.messages) != 1 || recorder.messages[0] != fmt.Sprint("INFO ", "hello", "server", "abc", "stream", "console", "type", "line") {
+		t.Fatalf("unexpected messages: %v", recorder.messages)
+	}
+}
+
+func TestApi_logger(t *testing.T) {
+	recorder := &recordingLogger{}
+	api := newTestApi(t, &Options{
+		Cookies: []*http.Cookie{{Name: "ATERNOS_SERVER", Value: "abc"}},
+		Logger:  recorder,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true}`))
+	})
+
+	if err := api.ajax(request{path: "ajax/server/stop", ajax: true, idempotent: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := fmt.Sprint("DEBUG ", "request done", "server", "abc", "endpoint", "ajax/server/stop", "attempt", 1, "status", 200)
+	if len(recorder.messages) != 1 || recorder.messages[0] != expected {
+		t.Fatalf("unexpected messages: %v", recorder.messages)
+	}
+}
@@ -0,0 +1,62 @@
+package aternos_api
+
+import "encoding/json"
+
+// MessageHandler handles websocket messages as they are received.
+type MessageHandler interface {
+	HandleMessage(msg WebsocketMessage)
+}
+
+// MessageHandlerFunc is an adapter to use an ordinary function as a MessageHandler.
+type MessageHandlerFunc func(msg WebsocketMessage)
+
+func (f MessageHandlerFunc) HandleMessage(msg WebsocketMessage) {
+	f(msg)
+}
+
+// AddHandler registers a handler that is called with each received message, right before it's sent to the Message channel.
+// It returns a function that removes the handler again.
+//
+// Handlers are called from the goroutine that receives messages, so they shouldn't block.
+// Note that the Message channel must still be read for messages to be received.
+func (w *Websocket) AddHandler(handler MessageHandler) (remove func()) {
+	w.handlersMu.Lock()
+	defer w.handlersMu.Unlock()
+
+	id := w.nextHandlerId
+	w.nextHandlerId++
+
+	if w.handlers == nil {
+		w.handlers = make(map[int]MessageHandler)
+	}
+	w.handlers[id] = handler
+
+	return func() {
+		w.handlersMu.Lock()
+		defer w.handlersMu.Unlock()
+		delete(w.handlers, id)
+	}
+}
+
+// handle calls all registered handlers with given message, in the order they were added.
+func (w *Websocket) handle(msg WebsocketMessage) {
+	w.handlersMu.Lock()
+	handlers := make([]MessageHandler, 0, len(w.handlers))
+	for id := 0; id < w.nextHandlerId; id++ {
+		if handler, ok := w.handlers[id]; ok {
+			handlers = append(handlers, handler)
+		}
+	}
+	w.handlersMu.Unlock()
+
+	for _, handler := range handlers {
+		handler.HandleMessage(msg)
+	}
+}
+
+// serverInfo decodes the server info of a status message.
+func (msg WebsocketMessage) serverInfo() (ServerInfo, error) {
+	var info ServerInfo
+	err := json.Unmarshal(msg.MessageBytes, &info)
+	return info, err
+}
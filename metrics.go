@@ -0,0 +1,220 @@
+package aternos_api
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Default time span of the rolling metrics windows.
+	defaultMetricsWindow = 5 * time.Minute
+
+	// Maximum amount of samples kept per window, regardless of its time span.
+	maxMetricsSamples = 1000
+
+	// Ticks per second of a Minecraft server that isn't lagging.
+	maxTPS = 20
+)
+
+// SampleStats summarizes the samples of a rolling window.
+// All values are zero if there are no samples.
+type SampleStats struct {
+	// Amount of samples in the window.
+	Count int
+
+	// Most recent sample.
+	Last float64
+
+	Min  float64
+	Max  float64
+	Mean float64
+
+	// 95th percentile.
+	P95 float64
+}
+
+// HeapMetrics summarizes memory usage.
+type HeapMetrics struct {
+	// Used memory in bytes.
+	Usage SampleStats
+
+	// Used memory as a percentage of the memory that's available to the server (ServerInfo.RAM).
+	// Zero if the available memory isn't known yet, because no status message has been received.
+	Percentage SampleStats
+
+	// Memory available to the server in bytes, zero if unknown.
+	Available int64
+}
+
+// TickMetrics summarizes server performance.
+type TickMetrics struct {
+	// Average time in milliseconds it takes the server to process a tick.
+	TickTime SampleStats
+
+	// Ticks per second, derived from the tick time and capped at 20.
+	// A value below 20 means the server is lagging.
+	TPS SampleStats
+}
+
+// MetricsSnapshot is a summary of the heap and tick samples that were received within the metrics window.
+//
+// The heap and tick streams must be started for samples to be received, see Websocket.StartHeapInfoStream
+// and Websocket.StartTickStream.
+type MetricsSnapshot struct {
+	// Time the snapshot was taken.
+	Time time.Time
+
+	// Time span of the window that's summarized.
+	Window time.Duration
+
+	Heap HeapMetrics
+	Tick TickMetrics
+}
+
+// metricSample is a value at a point in time.
+type metricSample struct {
+	time  time.Time
+	value float64
+}
+
+// metricWindow keeps the samples of a rolling time window.
+type metricWindow []metricSample
+
+// add appends a sample and drops samples that fell out of the window.
+func (w metricWindow) add(sample metricSample, window time.Duration) metricWindow {
+	w = append(w, sample)
+	return w.trim(sample.time, window)
+}
+
+// trim drops samples that are older than given window.
+func (w metricWindow) trim(now time.Time, window time.Duration) metricWindow {
+	i := 0
+	for i < len(w) && (now.Sub(w[i].time) > window || len(w)-i > maxMetricsSamples) {
+		i++
+	}
+	return w[i:]
+}
+
+// stats summarizes the samples, mapping each value with given function first.
+func (w metricWindow) stats(mapping func(v float64) float64) SampleStats {
+	if len(w) == 0 {
+		return SampleStats{}
+	}
+
+	values := make([]float64, len(w))
+	sum := 0.0
+	for i, sample := range w {
+		values[i] = mapping(sample.value)
+		sum += values[i]
+	}
+
+	stats := SampleStats{Count: len(values), Last: values[len(values)-1], Mean: sum / float64(len(values))}
+
+	sort.Float64s(values)
+	stats.Min = values[0]
+	stats.Max = values[len(values)-1]
+	stats.P95 = values[int(math.Ceil(0.95*float64(len(values))))-1]
+
+	return stats
+}
+
+// metrics aggregates heap and tick messages into rolling windows.
+type metrics struct {
+	mu     sync.Mutex
+	window time.Duration
+	heap   metricWindow
+	tick   metricWindow
+
+	// Memory available to the server in MB.
+	ram int
+}
+
+func newMetrics(window time.Duration) *metrics {
+	if window <= 0 {
+		window = defaultMetricsWindow
+	}
+	return &metrics{window: window}
+}
+
+// observe records the sample of given message, if any.
+func (m *metrics) observe(msg WebsocketMessage, now time.Time) {
+	switch msg.Type {
+	case "heap":
+		var heap Heap
+		if err := json.Unmarshal(msg.Data.ContentBytes, &heap); err != nil {
+			return
+		}
+		m.mu.Lock()
+		m.heap = m.heap.add(metricSample{now, float64(heap.Usage)}, m.window)
+		m.mu.Unlock()
+	case "tick":
+		var tick Tick
+		if err := json.Unmarshal(msg.Data.ContentBytes, &tick); err != nil {
+			return
+		}
+		m.mu.Lock()
+		m.tick = m.tick.add(metricSample{now, float64(tick.AverageTickTime)}, m.window)
+		m.mu.Unlock()
+	case "status":
+		info, err := msg.serverInfo()
+		if err != nil || info.RAM <= 0 {
+			return
+		}
+		m.mu.Lock()
+		m.ram = info.RAM
+		m.mu.Unlock()
+	}
+}
+
+// snapshot summarizes the current windows.
+func (m *metrics) snapshot(now time.Time) MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.heap = m.heap.trim(now, m.window)
+	m.tick = m.tick.trim(now, m.window)
+
+	available := int64(m.ram) * 1024 * 1024
+
+	snapshot := MetricsSnapshot{
+		Time:   now,
+		Window: m.window,
+		Heap: HeapMetrics{
+			Usage:     m.heap.stats(identity),
+			Available: available,
+		},
+		Tick: TickMetrics{
+			TickTime: m.tick.stats(identity),
+			TPS:      m.tick.stats(tps),
+		},
+	}
+
+	if available > 0 {
+		snapshot.Heap.Percentage = m.heap.stats(func(v float64) float64 {
+			return v / float64(available) * 100
+		})
+	}
+
+	return snapshot
+}
+
+func identity(v float64) float64 {
+	return v
+}
+
+// tps converts a tick time in milliseconds to ticks per second.
+func tps(tickTime float64) float64 {
+	if tickTime <= 1000/maxTPS {
+		return maxTPS
+	}
+	return 1000 / tickTime
+}
+
+// Metrics returns a summary of the heap and tick samples received within the metrics window (see Options.MetricsWindow).
+// It's safe to call it concurrently, e.g. from a dashboard or a lag alert.
+func (w *Websocket) Metrics() MetricsSnapshot {
+	return w.metrics.snapshot(time.Now())
+}
@@ -0,0 +1,86 @@
+package aternos_api
+
+import (
+	"github.com/gorilla/websocket"
+	"testing"
+	"time"
+)
+
+func heapMessage(usage string) WebsocketMessage {
+	return WebsocketMessage{Stream: "heap", Type: "heap", Data: Data{ContentBytes: []byte(`{"usage":` + usage + `}`)}}
+}
+
+func tickMessage(tickTime string) WebsocketMessage {
+	return WebsocketMessage{Stream: "tick", Type: "tick", Data: Data{ContentBytes: []byte(`{"averageTickTime":` + tickTime + `}`)}}
+}
+
+func TestMetrics_snapshot(t *testing.T) {
+	m := newMetrics(time.Minute)
+	start := time.Now()
+
+	m.observe(WebsocketMessage{Type: "status", MessageBytes: []byte(`{"status":1,"ram":100}`)}, start)
+	for i, tickTime := range []string{"25", "50", "10", "100"} {
+		m.observe(tickMessage(tickTime), start.Add(time.Duration(i)*time.Second))
+	}
+	for i, usage := range []string{"10485760", "52428800", "31457280"} {
+		m.observe(heapMessage(usage), start.Add(time.Duration(i)*time.Second))
+	}
+
+	snapshot := m.snapshot(start.Add(5 * time.Second))
+
+	tick := snapshot.Tick
+	if tick.TickTime.Count != 4 || tick.TickTime.Min != 10 || tick.TickTime.Max != 100 || tick.TickTime.Last != 100 || tick.TickTime.P95 != 100 {
+		t.Fatalf("unexpected tick time stats %+v", tick.TickTime)
+	}
+	if tick.TickTime.Mean != 46.25 {
+		t.Fatalf("expected a mean tick time of 46.25, got %v", tick.TickTime.Mean)
+	}
+	if tick.TPS.Max != 20 || tick.TPS.Min != 10 || tick.TPS.Last != 10 {
+		t.Fatalf("unexpected TPS stats %+v", tick.TPS)
+	}
+
+	heap := snapshot.Heap
+	if heap.Available != 100*1024*1024 {
+		t.Fatalf("expected 100MB available, got %d", heap.Available)
+	}
+	if heap.Percentage.Min != 10 || heap.Percentage.Max != 50 || heap.Percentage.Last != 30 {
+		t.Fatalf("unexpected heap percentage stats %+v", heap.Percentage)
+	}
+
+	// Samples that fell out of the window are dropped.
+	snapshot = m.snapshot(start.Add(time.Minute + 1500*time.Millisecond))
+	if snapshot.Tick.TickTime.Count != 2 || snapshot.Heap.Usage.Count != 1 {
+		t.Fatalf("expected old samples to be dropped, got %d tick and %d heap samples", snapshot.Tick.TickTime.Count, snapshot.Heap.Usage.Count)
+	}
+}
+
+func TestMetrics_snapshot_unknownRAM(t *testing.T) {
+	m := newMetrics(0)
+	m.observe(heapMessage("1024"), time.Now())
+
+	snapshot := m.snapshot(time.Now())
+	if snapshot.Window != defaultMetricsWindow {
+		t.Fatalf("expected default window, got %s", snapshot.Window)
+	}
+	if snapshot.Heap.Usage.Last != 1024 || snapshot.Heap.Percentage.Count != 0 {
+		t.Fatalf("unexpected heap stats %+v", snapshot.Heap)
+	}
+}
+
+func TestWebsocket_Metrics(t *testing.T) {
+	wss := newTestWebsocket(t, func(conn *websocket.Conn) {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"tick","type":"tick","data":{"averageTickTime":100}}`))
+		conn.ReadMessage()
+	})
+	defer wss.Close()
+
+	select {
+	case <-wss.Message:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+
+	if tps := wss.Metrics().Tick.TPS.Last; tps != 10 {
+		t.Fatalf("expected 10 TPS, got %v", tps)
+	}
+}
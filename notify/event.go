@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"text/template"
+	"time"
+)
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	// StatusChanged means the server status changed.
+	StatusChanged EventType = "status_changed"
+
+	// QueueTurn means it's the server's turn in queue and starting it must be confirmed.
+	QueueTurn EventType = "queue_turn"
+
+	// PlayerJoined means a player joined the server.
+	PlayerJoined EventType = "player_joined"
+
+	// BackupCompleted means a backup has been completed.
+	BackupCompleted EventType = "backup_completed"
+
+	// Error means the server crashed or an error was reported with Notifier.Error.
+	Error EventType = "error"
+)
+
+// Event is posted to webhooks.
+// It's the payload of webhooks with FormatJSON, and the data of message templates.
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Name and address of the server.
+	Server  string `json:"server,omitempty"`
+	Address string `json:"address,omitempty"`
+
+	// Current and previous status, e.g. "online".
+	Status         string `json:"status,omitempty"`
+	PreviousStatus string `json:"previousStatus,omitempty"`
+
+	// Player that joined.
+	Player string `json:"player,omitempty"`
+
+	// ID of the completed backup.
+	Backup string `json:"backup,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	// Message rendered from the template of the event type.
+	Message string `json:"message"`
+}
+
+// DefaultTemplates returns the message templates that are used by default.
+func DefaultTemplates() map[EventType]*template.Template {
+	texts := map[EventType]string{
+		StatusChanged:   `Server {{.Server}} is {{.Status}}{{if .PreviousStatus}} (was {{.PreviousStatus}}){{end}}.`,
+		QueueTurn:       `It's the turn of server {{.Server}} in queue, it must be confirmed to start.`,
+		PlayerJoined:    `{{.Player}} joined {{.Server}}.`,
+		BackupCompleted: `Backup of server {{.Server}} completed.`,
+		Error:           `{{if .Server}}Server {{.Server}}: {{end}}{{.Error}}`,
+	}
+
+	templates := make(map[EventType]*template.Template, len(texts))
+	for t, text := range texts {
+		templates[t] = template.Must(template.New(string(t)).Parse(text))
+	}
+
+	return templates
+}
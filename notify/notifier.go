@@ -0,0 +1,293 @@
+// Package notify posts server lifecycle events to webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Notifier posts events to webhooks.
+//
+// Events are derived from websocket messages, by registering it with Websocket.AddHandler,
+// and posted by Run.
+type Notifier struct {
+	Webhooks []Webhook
+
+	// Message templates by event type, executed with the Event as data.
+	// Events without template have an empty message.
+	Templates map[EventType]*template.Template
+
+	// Client to post events with.
+	Client *http.Client
+
+	// Maximum amount of attempts to post an event to a webhook.
+	MaxAttempts int
+
+	// Delay before the first retry.
+	// The delay doubles after each attempt, up to MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// Maximum amount of events that wait to be posted, after which new events are dropped.
+	MaxPending int
+
+	// Called when an event couldn't be posted to a webhook.
+	// It's called from the goroutine that executes Run.
+	OnError func(webhook Webhook, event Event, err error)
+
+	players *aternos.PlayerTracker
+
+	mu   sync.Mutex
+	info *aternos.ServerInfo
+
+	// Whether player events are ignored, while the players that are online at first are observed.
+	ignorePlayers bool
+
+	backups map[string]bool
+	pending []Event
+	updates chan struct{}
+}
+
+// New allocates a new Notifier with default settings that posts to given webhooks.
+func New(webhooks ...Webhook) *Notifier {
+	n := &Notifier{
+		Webhooks:      webhooks,
+		Templates:     DefaultTemplates(),
+		Client:        &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:   5,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: time.Minute,
+		MaxPending:    100,
+		backups:       make(map[string]bool),
+		updates:       make(chan struct{}, 1),
+	}
+
+	n.players = aternos.NewPlayerTracker(nil)
+	n.players.OnEvent = func(event aternos.PlayerEvent) {
+		n.mu.Lock()
+		ignore := n.ignorePlayers
+		n.mu.Unlock()
+
+		if event.Type == aternos.PlayerJoined && !ignore {
+			n.enqueue(Event{Type: PlayerJoined, Time: event.Time, Player: event.Player})
+		}
+	}
+
+	return n
+}
+
+// HandleMessage implements aternos.MessageHandler.
+// It observes status, console and backup_progress messages.
+func (n *Notifier) HandleMessage(msg aternos.WebsocketMessage) {
+	switch msg.Type {
+	case "status":
+		var info aternos.ServerInfo
+		if err := json.Unmarshal(msg.MessageBytes, &info); err == nil {
+			n.Observe(info)
+		}
+	case "line":
+		n.players.HandleMessage(msg)
+	case "backup_progress":
+		var backup aternos.BackupProgress
+		if err := json.Unmarshal(msg.MessageBytes, &backup); err == nil {
+			n.ObserveBackup(backup)
+		}
+	}
+}
+
+// Observe diffs given server info with the previously observed one.
+// The first observed server info only serves as reference, it doesn't produce events.
+func (n *Notifier) Observe(info aternos.ServerInfo) {
+	n.mu.Lock()
+	previous := n.info
+	n.info = &info
+	n.ignorePlayers = previous == nil
+	n.mu.Unlock()
+
+	now := time.Now()
+
+	if previous != nil && previous.Status != info.Status {
+		n.enqueue(Event{
+			Type:           StatusChanged,
+			Time:           now,
+			Status:         info.Status.String(),
+			PreviousStatus: previous.Status.String(),
+		})
+
+		if info.Status == aternos.Crashed {
+			n.enqueue(Event{Type: Error, Time: now, Status: info.Status.String(), Error: "server crashed"})
+		}
+	}
+
+	if info.Status == aternos.Preparing && info.Queue.Status == aternos.QueuePending &&
+		(previous == nil || previous.Status != aternos.Preparing || previous.Queue.Status != aternos.QueuePending) {
+		n.enqueue(Event{Type: QueueTurn, Time: now, Status: info.Status.String()})
+	}
+
+	n.players.Observe(info)
+
+	n.mu.Lock()
+	n.ignorePlayers = false
+	n.mu.Unlock()
+}
+
+// ObserveBackup produces an event once given backup is done.
+func (n *Notifier) ObserveBackup(backup aternos.BackupProgress) {
+	if !backup.Done {
+		return
+	}
+
+	n.mu.Lock()
+	seen := n.backups[backup.Id]
+	n.backups[backup.Id] = true
+	n.mu.Unlock()
+
+	if !seen {
+		n.enqueue(Event{Type: BackupCompleted, Time: time.Now(), Backup: backup.Id})
+	}
+}
+
+// Error posts an Error event, e.g. when the server failed to start.
+func (n *Notifier) Error(err error) {
+	n.enqueue(Event{Type: Error, Time: time.Now(), Error: err.Error()})
+}
+
+// enqueue completes given event with the current server details and queues it to be posted.
+func (n *Notifier) enqueue(event Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.info != nil {
+		event.Server = n.info.Name
+		event.Address = n.info.Address
+	}
+
+	if n.MaxPending > 0 && len(n.pending) >= n.MaxPending {
+		return
+	}
+	n.pending = append(n.pending, event)
+
+	select {
+	case n.updates <- struct{}{}:
+	default:
+	}
+}
+
+// Run posts queued events until ctx is done.
+// Events are posted in the order they occurred.
+func (n *Notifier) Run(ctx context.Context) error {
+	for {
+		n.mu.Lock()
+		pending := n.pending
+		n.pending = nil
+		n.mu.Unlock()
+
+		for _, event := range pending {
+			n.post(ctx, event)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-n.updates:
+		}
+	}
+}
+
+// post renders the message of given event and posts it to the webhooks that accept it.
+func (n *Notifier) post(ctx context.Context, event Event) {
+	if tmpl, ok := n.Templates[event.Type]; ok {
+		var message bytes.Buffer
+		if err := tmpl.Execute(&message, event); err == nil {
+			event.Message = message.String()
+		} else {
+			event.Message = fmt.Sprintf("%s (template error: %s)", event.Type, err)
+		}
+	}
+
+	for _, webhook := range n.Webhooks {
+		if !webhook.accepts(event.Type) {
+			continue
+		}
+		if err := n.deliver(ctx, webhook, event); err != nil && n.OnError != nil {
+			n.OnError(webhook, event, err)
+		}
+	}
+}
+
+// deliver posts an event to a webhook, retrying transient failures with exponential backoff.
+func (n *Notifier) deliver(ctx context.Context, webhook Webhook, event Event) error {
+	payload, err := webhook.payload(event)
+	if err != nil {
+		return err
+	}
+
+	delay := n.RetryDelay
+
+	for attempt := 1; ; attempt++ {
+		req, err := webhook.request(payload)
+		if err != nil {
+			return err
+		}
+
+		var wait time.Duration
+		res, err := n.Client.Do(req.WithContext(ctx))
+		if err == nil {
+			wait = retryAfter(res)
+			err = checkResponse(res)
+		}
+
+		if err == nil || !retryable(err) || attempt >= n.MaxAttempts {
+			return err
+		}
+
+		if wait < delay {
+			wait = delay
+		}
+		if n.MaxRetryDelay > 0 && wait > n.MaxRetryDelay {
+			wait = n.MaxRetryDelay
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+	}
+}
+
+// retryable reports whether posting an event failed due to a transient error.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	// Transport errors, e.g. timeouts and refused connections.
+	return true
+}
+
+// retryAfter returns the delay that the Retry-After header of a rate limited response asks for.
+func retryAfter(res *http.Response) time.Duration {
+	if res.StatusCode != http.StatusTooManyRequests {
+		return 0
+	}
+
+	seconds, err := strconv.ParseFloat(res.Header.Get("Retry-After"), 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
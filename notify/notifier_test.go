@@ -0,0 +1,236 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	aternos "github.com/sleeyax/aternos-api"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testReceiver records the requests that are posted to it.
+type testReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	received chan struct{}
+}
+
+func newTestReceiver(t *testing.T, status func(attempt int) int) *testReceiver {
+	r := &testReceiver{received: make(chan struct{}, 100)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		r.mu.Lock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		attempt := len(r.requests)
+		r.mu.Unlock()
+
+		if status != nil {
+			w.WriteHeader(status(attempt))
+		}
+		r.received <- struct{}{}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// wait waits until given amount of requests have been received.
+func (r *testReceiver) wait(t *testing.T, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		select {
+		case <-r.received:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d requests, got %d", count, i)
+		}
+	}
+}
+
+func runNotifier(t *testing.T, n *Notifier) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func statusMessage(t *testing.T, info aternos.ServerInfo) aternos.WebsocketMessage {
+	b, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aternos.WebsocketMessage{Stream: "status", Type: "status", Message: string(b), MessageBytes: b}
+}
+
+func TestNotifier_events(t *testing.T) {
+	receiver := newTestReceiver(t, nil)
+
+	n := New(Webhook{URL: receiver.URL, Secret: "secret"})
+	runNotifier(t, n)
+
+	info := aternos.ServerInfo{Name: "test", Address: "test.aternos.me", Status: aternos.Offline}
+	n.HandleMessage(statusMessage(t, info))
+
+	info.Status = aternos.Preparing
+	info.Queue.Status = aternos.QueuePending
+	n.HandleMessage(statusMessage(t, info))
+
+	info.Status = aternos.Online
+	info.PlayerList = []string{"Steve"}
+	n.HandleMessage(statusMessage(t, info))
+
+	backup, _ := json.Marshal(aternos.BackupProgress{Id: "1", Progress: 100, Done: true})
+	n.HandleMessage(aternos.WebsocketMessage{Type: "backup_progress", MessageBytes: backup})
+	n.HandleMessage(aternos.WebsocketMessage{Type: "backup_progress", MessageBytes: backup})
+
+	info.Status = aternos.Crashed
+	n.HandleMessage(statusMessage(t, info))
+
+	n.Error(errors.New("failed to start"))
+
+	expected := []struct {
+		typ     EventType
+		message string
+	}{
+		{StatusChanged, "Server test is preparing (was offline)."},
+		{QueueTurn, "It's the turn of server test in queue, it must be confirmed to start."},
+		{StatusChanged, "Server test is online (was preparing)."},
+		{PlayerJoined, "Steve joined test."},
+		{BackupCompleted, "Backup of server test completed."},
+		{StatusChanged, "Server test is crashed (was online)."},
+		{Error, "Server test: server crashed"},
+		{Error, "Server test: failed to start"},
+	}
+	receiver.wait(t, len(expected))
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if len(receiver.bodies) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(receiver.bodies))
+	}
+
+	for i, e := range expected {
+		var event Event
+		if err := json.Unmarshal(receiver.bodies[i], &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != e.typ || event.Message != e.message {
+			t.Errorf("event %d: expected %s %q, got %s %q", i, e.typ, e.message, event.Type, event.Message)
+		}
+		if event.Address != "test.aternos.me" {
+			t.Errorf("event %d: expected address to be set, got %q", i, event.Address)
+		}
+		if signature := receiver.requests[i].Header.Get(SignatureHeader); signature != Sign("secret", receiver.bodies[i]) {
+			t.Errorf("event %d: invalid signature %q", i, signature)
+		}
+	}
+}
+
+func TestNotifier_formats(t *testing.T) {
+	discord := newTestReceiver(t, nil)
+	slack := newTestReceiver(t, nil)
+
+	n := New(
+		Webhook{URL: discord.URL, Format: FormatDiscord},
+		Webhook{URL: slack.URL, Format: FormatSlack, Events: []EventType{StatusChanged}},
+	)
+	runNotifier(t, n)
+
+	n.Error(errors.New("boom"))
+	n.Observe(aternos.ServerInfo{Name: "test", Status: aternos.Offline})
+	n.Observe(aternos.ServerInfo{Name: "test", Status: aternos.Starting})
+
+	discord.wait(t, 2)
+	slack.wait(t, 1)
+
+	discord.mu.Lock()
+	defer discord.mu.Unlock()
+	if body := string(discord.bodies[0]); body != `{"content":"boom"}` {
+		t.Errorf("unexpected discord payload %s", body)
+	}
+
+	slack.mu.Lock()
+	defer slack.mu.Unlock()
+	if len(slack.bodies) != 1 || string(slack.bodies[0]) != `{"text":"Server test is starting (was offline)."}` {
+		t.Errorf("expected only the status change to be posted to slack, got %q", slack.bodies)
+	}
+}
+
+func TestNotifier_retry(t *testing.T) {
+	receiver := newTestReceiver(t, func(attempt int) int {
+		switch attempt {
+		case 1:
+			return http.StatusBadGateway
+		case 2:
+			return http.StatusTooManyRequests
+		case 3:
+			return http.StatusOK
+		default:
+			return http.StatusBadRequest
+		}
+	})
+
+	var mu sync.Mutex
+	var failures []error
+
+	n := New(Webhook{URL: receiver.URL})
+	n.RetryDelay = time.Millisecond
+	n.OnError = func(webhook Webhook, event Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, err)
+	}
+	runNotifier(t, n)
+
+	// Delivered on the third attempt.
+	n.Error(errors.New("first"))
+	receiver.wait(t, 3)
+
+	// Not retried after a client error.
+	n.Error(errors.New("second"))
+	receiver.wait(t, 1)
+
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var statusErr *StatusError
+	if len(failures) != 1 || !errors.As(failures[0], &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a single failure due to a bad request, got %v", failures)
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	if len(receiver.requests) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(receiver.requests))
+	}
+}
+
+func TestNotifier_ignoresInitialPlayers(t *testing.T) {
+	n := New()
+
+	n.Observe(aternos.ServerInfo{Status: aternos.Online, PlayerList: []string{"Steve"}})
+	n.Observe(aternos.ServerInfo{Status: aternos.Online, PlayerList: []string{"Steve", "Alex"}})
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.pending) != 1 || n.pending[0].Player != "Alex" {
+		t.Fatalf("expected only Alex to join, got %+v", n.pending)
+	}
+}
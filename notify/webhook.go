@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Format is the payload format of a webhook.
+type Format string
+
+const (
+	// FormatJSON posts the Event as JSON.
+	FormatJSON Format = "json"
+
+	// FormatDiscord posts the message as a Discord webhook message.
+	FormatDiscord Format = "discord"
+
+	// FormatSlack posts the message as a Slack incoming webhook message.
+	FormatSlack Format = "slack"
+)
+
+// SignatureHeader is the request header that contains the HMAC signature of the request body,
+// if Webhook.Secret is set. Its value is formatted as "sha256=<hex digest>".
+const SignatureHeader = "X-Aternos-Signature"
+
+// Webhook is a URL to post events to.
+type Webhook struct {
+	URL string
+
+	// Payload format, defaults to FormatJSON.
+	Format Format
+
+	// Key to sign the request body with, using HMAC-SHA256 (see SignatureHeader).
+	// Empty means requests aren't signed.
+	Secret string
+
+	// Types of events to post.
+	// Empty means all events are posted.
+	Events []EventType
+}
+
+// accepts reports whether events of given type should be posted to the webhook.
+func (w Webhook) accepts(t EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// payload encodes the event in the format of the webhook.
+func (w Webhook) payload(event Event) ([]byte, error) {
+	switch w.Format {
+	case FormatJSON, "":
+		return json.Marshal(event)
+	case FormatDiscord:
+		return json.Marshal(map[string]string{"content": event.Message})
+	case FormatSlack:
+		return json.Marshal(map[string]string{"text": event.Message})
+	default:
+		return nil, fmt.Errorf("unknown webhook format %q", w.Format)
+	}
+}
+
+// request builds the request that posts given payload.
+func (w Webhook) request(payload []byte) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, payload))
+	}
+
+	return req, nil
+}
+
+// Sign returns the signature of given body, as sent in SignatureHeader.
+// Receivers should compare it to the header with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// StatusError is returned when a webhook responds with an unsuccessful status code.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// checkResponse returns a StatusError if the response isn't successful.
+func checkResponse(res *http.Response) error {
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		io.Copy(ioutil.Discard, res.Body)
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+	return &StatusError{StatusCode: res.StatusCode, Body: string(bytes.TrimSpace(body))}
+}
@@ -3,6 +3,7 @@ package aternos_api
 import (
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type Options struct {
@@ -19,4 +20,38 @@ type Options struct {
 	// Disables server SSL certificate checks.
 	// It's recommended to enable this only for debugging purposes, such as debugging traffic with a web debugging/HTTP/MITM proxy.
 	InsecureSkipVerify bool
+
+	// Optional policy that controls how requests are retried on transient failures, such as a connection reset or a 5xx response.
+	// Defaults to DefaultRetryPolicy.
+	//
+	// Requests that aren't idempotent, such as starting the server, are only retried when
+	// they either didn't reach Aternos or didn't take effect.
+	RetryPolicy *RetryPolicy
+
+	// Optional rate limiter to use.
+	//
+	// By default, a limiter is shared by all Api instances that use the same account (ATERNOS_SESSION)
+	// and another one by those that use the same proxy.
+	RateLimiter *RateLimiter
+
+	// Request budgets per endpoint of the shared rate limiters.
+	// Api instances that share a limiter should use the same budgets, since it uses those of the instance that created it.
+	// Defaults to DefaultRateLimits.
+	//
+	// This option is ignored when RateLimiter is specified.
+	RateLimits map[string]RateLimit
+
+	// Optional logger to write diagnostic messages to, such as failed requests and websocket errors.
+	// It accepts a *slog.Logger.
+	//
+	// Nothing is logged by default.
+	Logger Logger
+
+	// Optional tracer that records every HTTP exchange and websocket frame.
+	// Use it to export a HAR file and websocket transcript for debugging or bug reports.
+	Tracer *Tracer
+
+	// Time span of the rolling windows that Websocket.Metrics summarizes.
+	// Defaults to 5 minutes.
+	MetricsWindow time.Duration
 }
@@ -0,0 +1,252 @@
+package aternos_api
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PlayerEventType is the type of a PlayerEvent.
+type PlayerEventType string
+
+const (
+	PlayerJoined PlayerEventType = "join"
+	PlayerLeft   PlayerEventType = "leave"
+)
+
+// Sources of a PlayerEvent.
+const (
+	// The event was derived from the player list of a status message.
+	PlayerSourceStatus = "status"
+
+	// The event was derived from a console line.
+	PlayerSourceConsole = "console"
+)
+
+// PlayerEvent describes a player that joined or left the server.
+type PlayerEvent struct {
+	Type   PlayerEventType
+	Player string
+	Time   time.Time
+
+	// Duration of the session that ended.
+	// Only set when a player left.
+	Session time.Duration
+
+	// Either PlayerSourceStatus or PlayerSourceConsole.
+	Source string
+}
+
+var (
+	// Java edition, e.g. "[12:00:00] [Server thread/INFO]: Steve joined the game" or "[12:00:00 INFO]: Steve joined the game".
+	// The line is matched as a whole, so that chat messages that merely contain such a line are ignored.
+	javaPlayerLine = regexp.MustCompile(`^\[(?:[^\]]*\] \[Server thread/|[^\]]* )INFO\]: ([A-Za-z0-9_]{1,16}) (joined|left) the game\s*$`)
+
+	// Bedrock edition, e.g. "[2022-01-01 12:00:00 INFO] Player connected: Steve, xuid: 123".
+	bedrockPlayerLine = regexp.MustCompile(`^\[[^\]]*INFO\] Player (connected|disconnected): ([^,]+), xuid: [0-9]*\s*$`)
+)
+
+// parsePlayerLine returns the player that joined or left according to given console line, if any.
+func parsePlayerLine(line string) (PlayerEventType, string, bool) {
+	if m := javaPlayerLine.FindStringSubmatch(line); m != nil {
+		if m[2] == "joined" {
+			return PlayerJoined, m[1], true
+		}
+		return PlayerLeft, m[1], true
+	}
+
+	if m := bedrockPlayerLine.FindStringSubmatch(line); m != nil {
+		if m[1] == "connected" {
+			return PlayerJoined, m[2], true
+		}
+		return PlayerLeft, m[2], true
+	}
+
+	return "", "", false
+}
+
+// PlayerTracker emits join and leave events by diffing the player lists of successive status messages,
+// records session durations and adds them to the playtime totals of a PlaytimeStore.
+//
+// While the console stream is active (see Websocket.StartConsoleLogStream), join and leave lines are used too:
+// they're usually received before the next status message and catch sessions that are too short to show up in one.
+//
+// Feed it websocket messages, by registering it with Websocket.AddHandler, or server info by calling Observe.
+// A PlayerTracker is safe for concurrent use.
+type PlayerTracker struct {
+	// Called with each event.
+	// It's called synchronously, so it shouldn't block.
+	OnEvent func(event PlayerEvent)
+
+	// Time during which a player list that contradicts a console line is considered outdated.
+	ConsoleGrace time.Duration
+
+	// Optional logger to report store errors to.
+	Logger Logger
+
+	store PlaytimeStore
+
+	mu sync.Mutex
+
+	// Join time of each online player.
+	online map[string]time.Time
+
+	// Time of the most recent console line about each player.
+	console map[string]time.Time
+}
+
+// NewPlayerTracker allocates a new PlayerTracker that adds playtime to given store.
+// If store is nil, playtime is kept in memory.
+func NewPlayerTracker(store PlaytimeStore) *PlayerTracker {
+	if store == nil {
+		store = NewMemoryPlaytimeStore()
+	}
+
+	return &PlayerTracker{
+		ConsoleGrace: 30 * time.Second,
+		store:        store,
+		online:       make(map[string]time.Time),
+		console:      make(map[string]time.Time),
+	}
+}
+
+// HandleMessage implements MessageHandler.
+// It observes status messages and console lines.
+func (t *PlayerTracker) HandleMessage(msg WebsocketMessage) {
+	switch msg.Type {
+	case "status":
+		if info, err := msg.serverInfo(); err == nil {
+			t.Observe(info)
+		}
+	case "line":
+		if msg.Stream == "console" {
+			t.observeLine(msg.Data.Content, time.Now())
+		}
+	}
+}
+
+// Observe diffs the player list of given server info with the players that are currently online.
+// All players leave once the server isn't online anymore.
+func (t *PlayerTracker) Observe(info ServerInfo) {
+	t.observe(info, time.Now())
+}
+
+func (t *PlayerTracker) observe(info ServerInfo, now time.Time) {
+	var events []PlayerEvent
+
+	t.mu.Lock()
+
+	current := make(map[string]bool, len(info.PlayerList))
+	if info.Status == Online {
+		for _, player := range info.PlayerList {
+			current[player] = true
+		}
+	}
+
+	for player := range current {
+		if _, ok := t.online[player]; !ok && !t.recentConsoleLine(player, now) {
+			events = append(events, t.join(player, now, PlayerSourceStatus))
+		}
+	}
+
+	for player := range t.online {
+		if !current[player] && (info.Status != Online || !t.recentConsoleLine(player, now)) {
+			events = append(events, t.leave(player, now, PlayerSourceStatus))
+		}
+	}
+
+	t.mu.Unlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			return events[i].Type == PlayerLeft
+		}
+		return events[i].Player < events[j].Player
+	})
+
+	t.emit(events)
+}
+
+// observeLine handles a console line.
+func (t *PlayerTracker) observeLine(line string, now time.Time) {
+	eventType, player, ok := parsePlayerLine(line)
+	if !ok {
+		return
+	}
+
+	var events []PlayerEvent
+
+	t.mu.Lock()
+	t.console[player] = now
+	_, online := t.online[player]
+	switch {
+	case eventType == PlayerJoined && !online:
+		events = append(events, t.join(player, now, PlayerSourceConsole))
+	case eventType == PlayerLeft && online:
+		events = append(events, t.leave(player, now, PlayerSourceConsole))
+	}
+	t.mu.Unlock()
+
+	t.emit(events)
+}
+
+// recentConsoleLine reports whether a console line about given player was received within the grace period,
+// in which case the player list may not be up-to-date yet.
+func (t *PlayerTracker) recentConsoleLine(player string, now time.Time) bool {
+	at, ok := t.console[player]
+	return ok && now.Sub(at) < t.ConsoleGrace
+}
+
+func (t *PlayerTracker) join(player string, now time.Time, source string) PlayerEvent {
+	t.online[player] = now
+	return PlayerEvent{Type: PlayerJoined, Player: player, Time: now, Source: source}
+}
+
+func (t *PlayerTracker) leave(player string, now time.Time, source string) PlayerEvent {
+	session := now.Sub(t.online[player])
+	delete(t.online, player)
+	return PlayerEvent{Type: PlayerLeft, Player: player, Time: now, Session: session, Source: source}
+}
+
+// emit records the playtime of ended sessions and calls OnEvent.
+func (t *PlayerTracker) emit(events []PlayerEvent) {
+	for _, event := range events {
+		if event.Type == PlayerLeft {
+			if err := t.store.AddPlaytime(event.Player, event.Session); err != nil && t.Logger != nil {
+				t.Logger.Error("failed to store playtime", "player", event.Player, "error", err)
+			}
+		}
+		if t.OnEvent != nil {
+			t.OnEvent(event)
+		}
+	}
+}
+
+// Online returns the players that are currently online and the time they joined.
+func (t *PlayerTracker) Online() map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	online := make(map[string]time.Time, len(t.online))
+	for player, since := range t.online {
+		online[player] = since
+	}
+
+	return online
+}
+
+// Playtime returns the total playtime of each player, including the sessions that are still ongoing.
+func (t *PlayerTracker) Playtime() (map[string]time.Duration, error) {
+	playtime, err := t.store.Playtime()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for player, since := range t.Online() {
+		playtime[player] += now.Sub(since)
+	}
+
+	return playtime, nil
+}
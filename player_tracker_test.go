@@ -0,0 +1,128 @@
+package aternos_api
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func online(players ...string) ServerInfo {
+	return ServerInfo{Status: Online, Players: len(players), PlayerList: players}
+}
+
+func TestPlayerTracker_observe(t *testing.T) {
+	var events []PlayerEvent
+	tracker := NewPlayerTracker(nil)
+	tracker.OnEvent = func(event PlayerEvent) {
+		events = append(events, event)
+	}
+
+	start := time.Now()
+	tracker.observe(online("alice", "bob"), start)
+	tracker.observe(online("bob", "carol"), start.Add(time.Minute))
+	tracker.observe(ServerInfo{Status: Stopping, PlayerList: []string{"bob"}}, start.Add(3*time.Minute))
+
+	var got []string
+	for _, event := range events {
+		got = append(got, string(event.Type)+" "+event.Player)
+	}
+	want := []string{"join alice", "join bob", "leave alice", "join carol", "leave bob", "leave carol"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+
+	playtime, err := tracker.Playtime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want2 := map[string]time.Duration{"alice": time.Minute, "bob": 3 * time.Minute, "carol": 2 * time.Minute}
+	if !reflect.DeepEqual(playtime, want2) {
+		t.Fatalf("expected playtime %v, got %v", want2, playtime)
+	}
+}
+
+func TestPlayerTracker_console(t *testing.T) {
+	var events []PlayerEvent
+	tracker := NewPlayerTracker(nil)
+	tracker.OnEvent = func(event PlayerEvent) {
+		events = append(events, event)
+	}
+
+	start := time.Now()
+	tracker.observeLine("[12:00:00] [Server thread/INFO]: Steve joined the game", start)
+
+	// The player list isn't updated yet, which must not be mistaken for Steve leaving.
+	tracker.observe(online(), start.Add(time.Second))
+	tracker.observe(online("Steve"), start.Add(2*time.Second))
+
+	tracker.observeLine("[12:00:10] [Server thread/INFO]: Steve left the game", start.Add(10*time.Second))
+	tracker.observe(online("Steve"), start.Add(11*time.Second))
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	if events[0].Type != PlayerJoined || events[0].Source != PlayerSourceConsole {
+		t.Fatalf("unexpected join event %+v", events[0])
+	}
+	if events[1].Type != PlayerLeft || events[1].Session != 10*time.Second {
+		t.Fatalf("unexpected leave event %+v", events[1])
+	}
+
+	// Once the grace period has passed, the player list is trusted again.
+	tracker.observe(online("Steve"), start.Add(time.Minute))
+	if len(events) != 3 || events[2].Type != PlayerJoined || events[2].Source != PlayerSourceStatus {
+		t.Fatalf("expected Steve to join again, got %+v", events)
+	}
+}
+
+func TestParsePlayerLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		typ    PlayerEventType
+		player string
+		ok     bool
+	}{
+		{"[12:00:00] [Server thread/INFO]: Steve joined the game", PlayerJoined, "Steve", true},
+		{"[12:00:00 INFO]: Alex_2 left the game", PlayerLeft, "Alex_2", true},
+		{"[2022-01-01 12:00:00 INFO] Player connected: Bedrock Guy, xuid: 123", PlayerJoined, "Bedrock Guy", true},
+		{"[2022-01-01 12:00:00 INFO] Player disconnected: Bedrock Guy, xuid: 123", PlayerLeft, "Bedrock Guy", true},
+		{"[12:00:00] [Server thread/INFO]: <Steve> I joined the game", "", "", false},
+		{"[12:00:00] [Server thread/INFO]: <bob> ]: Notch joined the game", "", "", false},
+		{"[12:00:00 INFO]: <bob> ]: Notch left the game", "", "", false},
+		{"[12:00:00] [Async Chat Thread - #0/INFO]: <bob> Notch joined the game", "", "", false},
+		{"[12:00:00] [Server thread/INFO]: Steve joined the game, or not", "", "", false},
+	}
+
+	for _, test := range tests {
+		typ, player, ok := parsePlayerLine(test.line)
+		if typ != test.typ || player != test.player || ok != test.ok {
+			t.Errorf("%q: got %q %q %v", test.line, typ, player, ok)
+		}
+	}
+}
+
+func TestFilePlaytimeStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "playtime.json")
+
+	store := NewFilePlaytimeStore(path)
+	if playtime, err := store.Playtime(); err != nil || len(playtime) != 0 {
+		t.Fatalf("expected no playtime, got %v, %v", playtime, err)
+	}
+
+	if err := store.AddPlaytime("alice", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddPlaytime("alice", 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The playtime must survive a restart.
+	playtime, err := NewFilePlaytimeStore(path).Playtime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if playtime["alice"] != 90*time.Second {
+		t.Fatalf("expected 90s of playtime, got %s", playtime["alice"])
+	}
+}
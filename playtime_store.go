@@ -0,0 +1,121 @@
+package aternos_api
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// PlaytimeStore persists the total playtime of players.
+type PlaytimeStore interface {
+	// AddPlaytime adds given duration to the total playtime of a player.
+	AddPlaytime(player string, d time.Duration) error
+
+	// Playtime returns the total playtime of each player.
+	// The returned map may be modified by the caller.
+	Playtime() (map[string]time.Duration, error)
+}
+
+// MemoryPlaytimeStore is a PlaytimeStore that keeps playtime in memory.
+type MemoryPlaytimeStore struct {
+	mu       sync.Mutex
+	playtime map[string]time.Duration
+}
+
+// NewMemoryPlaytimeStore allocates a new, empty MemoryPlaytimeStore.
+func NewMemoryPlaytimeStore() *MemoryPlaytimeStore {
+	return &MemoryPlaytimeStore{playtime: make(map[string]time.Duration)}
+}
+
+func (s *MemoryPlaytimeStore) AddPlaytime(player string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playtime[player] += d
+	return nil
+}
+
+func (s *MemoryPlaytimeStore) Playtime() (map[string]time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	playtime := make(map[string]time.Duration, len(s.playtime))
+	for player, d := range s.playtime {
+		playtime[player] = d
+	}
+
+	return playtime, nil
+}
+
+// FilePlaytimeStore is a PlaytimeStore that persists playtime in a JSON file,
+// mapping each player to their playtime in seconds.
+type FilePlaytimeStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFilePlaytimeStore returns a FilePlaytimeStore that persists playtime at given path.
+// The file is created once playtime is added.
+func NewFilePlaytimeStore(path string) *FilePlaytimeStore {
+	return &FilePlaytimeStore{path: path}
+}
+
+func (s *FilePlaytimeStore) AddPlaytime(player string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	playtime, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	playtime[player] += d
+
+	return s.write(playtime)
+}
+
+func (s *FilePlaytimeStore) Playtime() (map[string]time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+func (s *FilePlaytimeStore) read() (map[string]time.Duration, error) {
+	playtime := make(map[string]time.Duration)
+
+	data, err := ioutil.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return playtime, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var seconds map[string]float64
+	if err = json.Unmarshal(data, &seconds); err != nil {
+		return nil, err
+	}
+
+	for player, n := range seconds {
+		playtime[player] = time.Duration(n * float64(time.Second))
+	}
+
+	return playtime, nil
+}
+
+// write replaces the file with given playtime.
+func (s *FilePlaytimeStore) write(playtime map[string]time.Duration) error {
+	seconds := make(map[string]float64, len(playtime))
+	for player, d := range playtime {
+		seconds[player] = d.Seconds()
+	}
+
+	data, err := json.MarshalIndent(seconds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data)
+}
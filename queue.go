@@ -1,5 +1,14 @@
 package aternos_api
 
+// Queue statuses, see Queue.Status.
+const (
+	// QueueWaiting means the server is waiting for its turn in queue.
+	QueueWaiting = "waiting"
+
+	// QueuePending means it's the server's turn and starting it must be confirmed, see AutoConfirmer.
+	QueuePending = "pending"
+)
+
 // QueueReduction is a simplified version of Queue that the server uses to notify queue reduction (queue_reduced).
 type QueueReduction struct {
 	// Unique number to identify the queue.
@@ -30,7 +39,7 @@ type Queue struct {
 	Percentage float32 `json:"percentage"`
 
 	// Status message.
-	// E.g "waiting".
+	// Either QueueWaiting or QueuePending.
 	Status string `json:"pending"`
 
 	// Time left in human readable format.
@@ -0,0 +1,211 @@
+package aternos_api
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+)
+
+// QueueSample is the position in queue at a point in time.
+type QueueSample struct {
+	Time time.Time
+
+	// Position in queue.
+	Position int
+
+	// Amount of people in queue.
+	Count int
+}
+
+// QueueEstimate is an estimation of the time left in queue.
+type QueueEstimate struct {
+	// Current position in queue.
+	Position int
+
+	// Current amount of people in queue.
+	Count int
+
+	// Smoothed amount of positions the queue advances per minute.
+	// Zero if not enough samples have been observed yet.
+	Throughput float64
+
+	// Estimated time left until it's our turn, based on Throughput.
+	// Falls back to the estimation of Aternos when the throughput is unknown.
+	ETA time.Duration
+
+	// Estimated time left according to Aternos (Queue.Minutes).
+	AternosETA time.Duration
+
+	// Upper bound of the time left according to Aternos (QueueReduction.MaxTime).
+	MaxTime time.Duration
+}
+
+// QueueTracker keeps track of the position in queue while the server is preparing,
+// estimates the time left and signals when it's time to confirm the server.
+//
+// Feed it websocket messages, by registering it with Websocket.AddHandler,
+// or server info obtained by polling, by calling Observe.
+// A QueueTracker is safe for concurrent use.
+type QueueTracker struct {
+	// Smoothing factor of the exponential moving average of the throughput, between 0 and 1.
+	// It's the weight of a sample that spans one minute: samples that span a longer time weigh more,
+	// so that a burst of status messages doesn't outweigh a longer period.
+	// Higher values give more weight to recent samples.
+	Smoothing float64
+
+	// Maximum amount of samples to keep in history.
+	MaxHistory int
+
+	mu         sync.Mutex
+	history    []QueueSample
+	throughput float64
+	hasSample  bool
+	queue      Queue
+	maxTime    int
+	pending    bool
+	confirm    chan struct{}
+}
+
+// NewQueueTracker allocates a new QueueTracker with default settings.
+func NewQueueTracker() *QueueTracker {
+	return &QueueTracker{
+		Smoothing:  0.3,
+		MaxHistory: 500,
+		confirm:    make(chan struct{}, 1),
+	}
+}
+
+// Confirm returns a channel that receives a value each time it's the server's turn in queue and
+// starting it must be confirmed.
+//
+// The channel is buffered, so a signal isn't lost when it's not being read at that moment.
+func (t *QueueTracker) Confirm() <-chan struct{} {
+	return t.confirm
+}
+
+// HandleMessage implements MessageHandler.
+// It observes status and queue_reduced messages.
+func (t *QueueTracker) HandleMessage(msg WebsocketMessage) {
+	switch msg.Type {
+	case "status":
+		if info, err := msg.serverInfo(); err == nil {
+			t.Observe(info)
+		}
+	case "queue_reduced":
+		var reduction QueueReduction
+		if err := json.Unmarshal(msg.MessageBytes, &reduction); err == nil {
+			t.ObserveReduction(reduction)
+		}
+	}
+}
+
+// Observe records the queue status of given server info.
+// The history is cleared once the server is no longer preparing.
+func (t *QueueTracker) Observe(info ServerInfo) {
+	t.observe(info, time.Now())
+}
+
+func (t *QueueTracker) observe(info ServerInfo, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if info.Status != Preparing {
+		t.reset()
+		return
+	}
+
+	t.queue = info.Queue
+
+	if info.Queue.Status == QueuePending {
+		if !t.pending {
+			t.pending = true
+			select {
+			case t.confirm <- struct{}{}:
+			default:
+			}
+		}
+	} else {
+		t.pending = false
+	}
+
+	sample := QueueSample{Time: now, Position: info.Queue.Position, Count: info.Queue.Count}
+
+	if n := len(t.history); n > 0 {
+		last := t.history[n-1]
+
+		// Moving back in queue means we're in a new queue, so start over.
+		if sample.Position > last.Position {
+			t.history = nil
+			t.throughput, t.hasSample = 0, false
+		} else if minutes := sample.Time.Sub(last.Time).Minutes(); minutes > 0 {
+			rate := float64(last.Position-sample.Position) / minutes
+			// A stalled queue yields a rate of zero, which is a sample too.
+			if !t.hasSample {
+				t.throughput, t.hasSample = rate, true
+			} else {
+				alpha := 1 - math.Pow(1-t.Smoothing, minutes)
+				t.throughput = alpha*rate + (1-alpha)*t.throughput
+			}
+		}
+	}
+
+	t.history = append(t.history, sample)
+	if t.MaxHistory > 0 && len(t.history) > t.MaxHistory {
+		t.history = t.history[len(t.history)-t.MaxHistory:]
+	}
+}
+
+// ObserveReduction records a queue reduction.
+func (t *QueueTracker) ObserveReduction(reduction QueueReduction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.queue.Count = reduction.Total
+	t.maxTime = reduction.MaxTime
+}
+
+// Estimate estimates the time left in queue.
+func (t *QueueTracker) Estimate() QueueEstimate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	estimate := QueueEstimate{
+		Position:   t.queue.Position,
+		Count:      t.queue.Count,
+		Throughput: t.throughput,
+		AternosETA: time.Duration(t.queue.Minutes) * time.Minute,
+		MaxTime:    time.Duration(t.maxTime) * time.Minute,
+	}
+
+	if t.throughput > 0 {
+		minutes := float64(t.queue.Position) / t.throughput
+		estimate.ETA = time.Duration(math.Round(minutes*60)) * time.Second
+	} else {
+		estimate.ETA = estimate.AternosETA
+	}
+
+	return estimate
+}
+
+// History returns the observed positions in queue, oldest first.
+func (t *QueueTracker) History() []QueueSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]QueueSample(nil), t.history...)
+}
+
+// Reset clears all observed data.
+func (t *QueueTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reset()
+}
+
+func (t *QueueTracker) reset() {
+	t.history = nil
+	t.throughput, t.hasSample = 0, false
+	t.queue = Queue{}
+	t.maxTime = 0
+	t.pending = false
+}
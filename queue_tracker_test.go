@@ -0,0 +1,158 @@
+package aternos_api
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"testing"
+	"time"
+)
+
+func preparing(position, count, minutes int, status string) ServerInfo {
+	return ServerInfo{
+		Status: Preparing,
+		Queue:  Queue{Position: position, Count: count, Minutes: minutes, Status: status},
+	}
+}
+
+func TestQueueTracker_estimate(t *testing.T) {
+	tracker := NewQueueTracker()
+	start := time.Now()
+
+	tracker.observe(preparing(20, 100, 15, QueueWaiting), start)
+
+	// Without throughput, the estimation of Aternos is used.
+	if estimate := tracker.Estimate(); estimate.ETA != 15*time.Minute || estimate.Throughput != 0 {
+		t.Fatalf("unexpected initial estimate %+v", estimate)
+	}
+
+	tracker.observe(preparing(18, 100, 14, QueueWaiting), start.Add(time.Minute))
+	tracker.observe(preparing(16, 100, 13, QueueWaiting), start.Add(2*time.Minute))
+
+	estimate := tracker.Estimate()
+	if estimate.Throughput != 2 {
+		t.Fatalf("expected a throughput of 2 positions/min, got %v", estimate.Throughput)
+	}
+	if estimate.ETA != 8*time.Minute {
+		t.Fatalf("expected an ETA of 8m, got %s", estimate.ETA)
+	}
+	if estimate.AternosETA != 13*time.Minute {
+		t.Fatalf("expected an Aternos ETA of 13m, got %s", estimate.AternosETA)
+	}
+	if n := len(tracker.History()); n != 3 {
+		t.Fatalf("expected 3 samples, got %d", n)
+	}
+
+	// A higher position means we're in a new queue.
+	tracker.observe(preparing(30, 100, 20, QueueWaiting), start.Add(3*time.Minute))
+	if n := len(tracker.History()); n != 1 {
+		t.Fatalf("expected history to be reset, got %d samples", n)
+	}
+	if estimate := tracker.Estimate(); estimate.Throughput != 0 {
+		t.Fatalf("expected throughput to be reset, got %v", estimate.Throughput)
+	}
+
+	tracker.observe(ServerInfo{Status: Online}, start.Add(4*time.Minute))
+	if n := len(tracker.History()); n != 0 {
+		t.Fatalf("expected history to be cleared, got %d samples", n)
+	}
+}
+
+func TestQueueTracker_estimate_stalled(t *testing.T) {
+	tracker := NewQueueTracker()
+	tracker.Smoothing = 0.5
+	start := time.Now()
+
+	// A stalled queue is a sample of zero, rather than no sample at all.
+	tracker.observe(preparing(20, 100, 15, QueueWaiting), start)
+	tracker.observe(preparing(20, 100, 15, QueueWaiting), start.Add(time.Minute))
+	tracker.observe(preparing(16, 100, 13, QueueWaiting), start.Add(2*time.Minute))
+
+	if estimate := tracker.Estimate(); estimate.Throughput != 2 {
+		t.Fatalf("expected a smoothed throughput of 2 positions/min, got %v", estimate.Throughput)
+	}
+}
+
+func TestQueueTracker_estimate_timeWeighted(t *testing.T) {
+	tracker := NewQueueTracker()
+	tracker.Smoothing = 0.5
+	start := time.Now()
+
+	// A sample that spans two minutes weighs as much as two successive samples of a minute each.
+	tracker.observe(preparing(20, 100, 15, QueueWaiting), start)
+	tracker.observe(preparing(20, 100, 15, QueueWaiting), start.Add(time.Minute))
+	tracker.observe(preparing(16, 100, 13, QueueWaiting), start.Add(3*time.Minute))
+
+	if estimate := tracker.Estimate(); estimate.Throughput != 1.5 {
+		t.Fatalf("expected a smoothed throughput of 1.5 positions/min, got %v", estimate.Throughput)
+	}
+
+	// A sample that spans a second barely moves the average, even though its rate is 60 positions/min.
+	tracker.observe(preparing(15, 100, 12, QueueWaiting), start.Add(3*time.Minute+time.Second))
+	if estimate := tracker.Estimate(); estimate.Throughput < 1.5 || estimate.Throughput > 2.5 {
+		t.Fatalf("expected a throughput between 1.5 and 2.5 positions/min, got %v", estimate.Throughput)
+	}
+}
+
+func TestQueueTracker_confirm(t *testing.T) {
+	tracker := NewQueueTracker()
+
+	tracker.Observe(preparing(1, 10, 1, QueueWaiting))
+	select {
+	case <-tracker.Confirm():
+		t.Fatal("unexpected confirm signal while waiting")
+	default:
+	}
+
+	tracker.Observe(preparing(0, 10, 0, QueuePending))
+	tracker.Observe(preparing(0, 10, 0, QueuePending))
+
+	select {
+	case <-tracker.Confirm():
+	default:
+		t.Fatal("expected confirm signal")
+	}
+
+	// The signal is only sent once per pending state.
+	select {
+	case <-tracker.Confirm():
+		t.Fatal("unexpected second confirm signal")
+	default:
+	}
+}
+
+func TestQueueTracker_handleMessage(t *testing.T) {
+	wss := newTestWebsocket(t, func(conn *websocket.Conn) {
+		// Wait for the handler to be registered.
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		info, _ := json.Marshal(preparing(5, 50, 3, QueueWaiting))
+		status, _ := json.Marshal(map[string]string{"type": "status", "message": string(info)})
+		reduced, _ := json.Marshal(map[string]string{"type": "queue_reduced", "message": `{"queue":0,"total":42,"maxtime":7}`})
+		conn.WriteMessage(websocket.TextMessage, status)
+		conn.WriteMessage(websocket.TextMessage, reduced)
+		conn.ReadMessage()
+	})
+	defer wss.Close()
+
+	tracker := NewQueueTracker()
+	remove := wss.AddHandler(tracker)
+	defer remove()
+
+	if err := wss.SendHeartBeat(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-wss.Message:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+	}
+
+	estimate := tracker.Estimate()
+	if estimate.Position != 5 || estimate.Count != 42 || estimate.MaxTime != 7*time.Minute {
+		t.Fatalf("unexpected estimate %+v", estimate)
+	}
+}
@@ -0,0 +1,337 @@
+package aternos_api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is the request budget of a token bucket.
+type RateLimit struct {
+	// Average amount of requests per second.
+	// Zero or less means unlimited.
+	Rate float64
+
+	// Maximum amount of requests that can be sent at once.
+	Burst int
+}
+
+// DefaultRateLimits returns the budgets that are used when Options.RateLimits isn't specified.
+//
+// The empty key is the budget of all endpoints that aren't explicitly listed.
+func DefaultRateLimits() map[string]RateLimit {
+	return map[string]RateLimit{
+		"":                  {Rate: 1, Burst: 5},
+		"server":            {Rate: 0.2, Burst: 3},
+		"ajax/server/start": {Rate: 0.1, Burst: 2},
+	}
+}
+
+// RateLimiter is a client-side token bucket rate limiter with a budget per endpoint.
+// It prevents Aternos from throttling or blocking the account for sending too many requests.
+//
+// A RateLimiter is safe for concurrent use and can be shared by multiple Api instances.
+type RateLimiter struct {
+	mu sync.Mutex
+
+	// Budget per endpoint.
+	limits map[string]RateLimit
+
+	// Token bucket per endpoint.
+	buckets map[string]*bucket
+
+	// Requests are held back until this time, as requested by a Retry-After header.
+	pausedUntil time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allocates a new RateLimiter with given budgets per endpoint.
+// Endpoints are paths relative to the Aternos base URL, such as "ajax/server/start".
+//
+// The empty key specifies the budget of all other endpoints.
+// Endpoints without a budget aren't limited.
+func NewRateLimiter(limits map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Wait blocks until a request to the given endpoint is allowed to be sent, or until ctx is done.
+// In the latter case ctx.Err() is returned and the request doesn't count towards the budget.
+func (l *RateLimiter) Wait(ctx context.Context, endpoint string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d := l.reserve(endpoint, time.Now())
+	if err := sleep(ctx, d); err != nil {
+		l.release(endpoint)
+		return err
+	}
+
+	return nil
+}
+
+// Pause holds back all requests for the given duration.
+func (l *RateLimiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// limit returns the key of the bucket of given endpoint and its budget.
+// ok is false if the endpoint isn't limited.
+func (l *RateLimiter) limit(endpoint string) (key string, limit RateLimit, ok bool) {
+	limit, ok = l.limits[endpoint]
+	if !ok {
+		endpoint = ""
+		limit, ok = l.limits[endpoint]
+	}
+	return endpoint, limit, ok && limit.Rate > 0
+}
+
+// burst returns the size of the bucket of given budget.
+func burst(limit RateLimit) float64 {
+	if limit.Burst < 1 {
+		return 1
+	}
+	return float64(limit.Burst)
+}
+
+// reserve takes a token from the bucket of given endpoint and returns how long to wait before it may be used.
+func (l *RateLimiter) reserve(endpoint string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	if l.pausedUntil.After(now) {
+		wait = l.pausedUntil.Sub(now)
+	}
+
+	key, limit, ok := l.limit(endpoint)
+	if !ok {
+		return wait
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst(limit), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+	if b.tokens > burst(limit) {
+		b.tokens = burst(limit)
+	}
+	b.last = now
+	b.tokens--
+
+	if b.tokens < 0 {
+		if d := time.Duration(-b.tokens / limit.Rate * float64(time.Second)); d > wait {
+			wait = d
+		}
+	}
+
+	return wait
+}
+
+// release puts back the token that reserve took from the bucket of given endpoint.
+func (l *RateLimiter) release(endpoint string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key, limit, ok := l.limit(endpoint)
+	if !ok {
+		return
+	}
+
+	if b, ok := l.buckets[key]; ok {
+		if b.tokens++; b.tokens > burst(limit) {
+			b.tokens = burst(limit)
+		}
+	}
+}
+
+// idle reports whether the limiter is in the same state as a new one at given time,
+// i.e. it isn't paused and all of its buckets are full.
+func (l *RateLimiter) idle(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pausedUntil.After(now) {
+		return false
+	}
+
+	for key, b := range l.buckets {
+		limit := l.limits[key]
+		if b.tokens+now.Sub(b.last).Seconds()*limit.Rate < burst(limit) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var (
+	sharedRateLimitersMu sync.Mutex
+	sharedRateLimiters   = make(map[string]*RateLimiter)
+)
+
+// withSharedRateLimiter calls f with the RateLimiter that is shared by all Api instances with the same key.
+// The limiter is created with given budgets if it doesn't exist yet.
+//
+// Idle limiters of other keys are removed, since they're in the same state as new ones.
+// f is called while holding the lock, so the limiter isn't removed while f uses it.
+func withSharedRateLimiter(key string, limits map[string]RateLimit, f func(limiter *RateLimiter)) {
+	sharedRateLimitersMu.Lock()
+	defer sharedRateLimitersMu.Unlock()
+
+	now := time.Now()
+	for k, limiter := range sharedRateLimiters {
+		if k != key && limiter.idle(now) {
+			delete(sharedRateLimiters, k)
+		}
+	}
+
+	limiter, ok := sharedRateLimiters[key]
+	if !ok {
+		limiter = NewRateLimiter(limits)
+		sharedRateLimiters[key] = limiter
+	}
+
+	f(limiter)
+}
+
+// rateLimiters are the rate limiters that must allow a request of an Api before it's sent.
+type rateLimiters struct {
+	// Options.RateLimiter, if specified. The shared limiters don't apply then.
+	custom *RateLimiter
+
+	// Keys of the shared limiters, by account and proxy.
+	// They're looked up for each request, because idle ones are removed in the meantime.
+	shared []string
+
+	// Budgets of the shared limiters.
+	limits map[string]RateLimit
+}
+
+// newRateLimiters returns the rate limiters that apply to the Api with given options.
+func newRateLimiters(options *Options) rateLimiters {
+	if options.RateLimiter != nil {
+		return rateLimiters{custom: options.RateLimiter}
+	}
+
+	r := rateLimiters{limits: options.RateLimits}
+	if r.limits == nil {
+		r.limits = DefaultRateLimits()
+	}
+
+	for _, cookie := range options.Cookies {
+		if cookie.Name == "ATERNOS_SESSION" {
+			r.shared = append(r.shared, "account:"+cookie.Value)
+		}
+	}
+
+	if options.Proxy != nil {
+		r.shared = append(r.shared, "proxy:"+options.Proxy.Host)
+	}
+
+	if len(r.shared) == 0 {
+		r.shared = append(r.shared, "default")
+	}
+
+	return r
+}
+
+// wait blocks until all limiters allow a request to the given endpoint, or until ctx is done.
+func (r rateLimiters) wait(ctx context.Context, endpoint string) error {
+	if r.custom != nil {
+		return r.custom.Wait(ctx, endpoint)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var d time.Duration
+	now := time.Now()
+	for _, key := range r.shared {
+		withSharedRateLimiter(key, r.limits, func(limiter *RateLimiter) {
+			if wait := limiter.reserve(endpoint, now); wait > d {
+				d = wait
+			}
+		})
+	}
+
+	if err := sleep(ctx, d); err != nil {
+		for _, key := range r.shared {
+			withSharedRateLimiter(key, r.limits, func(limiter *RateLimiter) {
+				limiter.release(endpoint)
+			})
+		}
+		return err
+	}
+
+	return nil
+}
+
+// pause holds back all requests for the given duration.
+func (r rateLimiters) pause(d time.Duration) {
+	if r.custom != nil {
+		r.custom.Pause(d)
+		return
+	}
+
+	for _, key := range r.shared {
+		withSharedRateLimiter(key, r.limits, func(limiter *RateLimiter) {
+			limiter.Pause(d)
+		})
+	}
+}
+
+// sleep waits for given duration, or until ctx is done in which case ctx.Err() is returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryAfter parses the Retry-After header of given response.
+// It returns 0 if the header isn't set or invalid.
+func retryAfter(header http.Header) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
@@ -0,0 +1,150 @@
+package aternos_api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_reserve(t *testing.T) {
+	limiter := NewRateLimiter(map[string]RateLimit{
+		"":       {Rate: 1, Burst: 2},
+		"server": {Rate: 0.5, Burst: 1},
+	})
+	now := time.Now()
+
+	// The burst is available immediately.
+	for i := 0; i < 2; i++ {
+		if d := limiter.reserve("ajax/server/stop", now); d != 0 {
+			t.Fatalf("request %d: expected no wait, got %s", i, d)
+		}
+	}
+	if d := limiter.reserve("ajax/server/stop", now); d != time.Second {
+		t.Fatalf("expected to wait 1s, got %s", d)
+	}
+
+	// Endpoints with their own budget don't share the default bucket.
+	if d := limiter.reserve("server", now); d != 0 {
+		t.Fatalf("expected no wait, got %s", d)
+	}
+	if d := limiter.reserve("server", now); d != 2*time.Second {
+		t.Fatalf("expected to wait 2s, got %s", d)
+	}
+
+	// Tokens are refilled over time.
+	if d := limiter.reserve("server", now.Add(6*time.Second)); d != 0 {
+		t.Fatalf("expected no wait, got %s", d)
+	}
+}
+
+func TestRateLimiter_Pause(t *testing.T) {
+	limiter := NewRateLimiter(nil)
+
+	limiter.Pause(time.Minute)
+
+	if d := limiter.reserve("server", time.Now()); d < 59*time.Second {
+		t.Fatalf("expected to wait about a minute, got %s", d)
+	}
+}
+
+func TestRateLimiters_shared(t *testing.T) {
+	session := &http.Cookie{Name: "ATERNOS_SESSION", Value: "TestRateLimiters_shared"}
+	proxy, _ := url.Parse("http://127.0.0.1:8888")
+
+	limits := map[string]RateLimit{"": {Rate: 0.001, Burst: 1}}
+	a := newRateLimiters(&Options{Cookies: []*http.Cookie{session}, RateLimits: limits})
+	b := newRateLimiters(&Options{Cookies: []*http.Cookie{session}, RateLimits: limits, Proxy: proxy})
+
+	if len(a.shared) != 1 || len(b.shared) != 2 {
+		t.Fatalf("unexpected amount of limiters: %d, %d", len(a.shared), len(b.shared))
+	}
+
+	// The burst of the account is used up by a, so b must wait.
+	if err := a.wait(context.Background(), "server"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx, "server"); err != context.DeadlineExceeded {
+		t.Fatalf("expected limiter to be shared by the same account, got %v", err)
+	}
+
+	custom := NewRateLimiter(nil)
+	if c := newRateLimiters(&Options{Cookies: []*http.Cookie{session}, RateLimiter: custom}); c.custom != custom || len(c.shared) != 0 {
+		t.Fatal("expected custom limiter to be used")
+	}
+}
+
+func TestRateLimiter_Wait_cancelled(t *testing.T) {
+	limiter := NewRateLimiter(map[string]RateLimit{"": {Rate: 1, Burst: 1}})
+
+	if err := limiter.Wait(context.Background(), "server"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, "server"); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// The cancelled request doesn't count, so the next one only waits for the first.
+	if d := limiter.reserve("server", time.Now()); d > time.Second {
+		t.Fatalf("expected to wait at most 1s, got %s", d)
+	}
+}
+
+func TestSharedRateLimiters_idle(t *testing.T) {
+	limits := map[string]RateLimit{"": {Rate: 1000, Burst: 1}}
+
+	withSharedRateLimiter("test:idle", limits, func(limiter *RateLimiter) {
+		limiter.reserve("server", time.Now())
+	})
+	withSharedRateLimiter("test:busy", map[string]RateLimit{"": {Rate: 0.001, Burst: 1}}, func(limiter *RateLimiter) {
+		limiter.reserve("server", time.Now())
+	})
+
+	// The idle limiter refills within a few milliseconds, after which it's removed.
+	time.Sleep(10 * time.Millisecond)
+	withSharedRateLimiter("test:other", limits, func(*RateLimiter) {})
+
+	sharedRateLimitersMu.Lock()
+	_, idle := sharedRateLimiters["test:idle"]
+	_, busy := sharedRateLimiters["test:busy"]
+	sharedRateLimitersMu.Unlock()
+
+	if idle || !busy {
+		t.Fatalf("expected only the idle limiter to be removed, got idle %v and busy %v", idle, busy)
+	}
+}
+
+func TestApi_do_tooManyRequests(t *testing.T) {
+	var calls int
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	start := time.Now()
+
+	// Throttled requests are retried even if they aren't idempotent, because Aternos didn't process them.
+	res, err := api.do(request{path: "ajax/server/start"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Close()
+
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected Retry-After to be respected, retried after %s", elapsed)
+	}
+}
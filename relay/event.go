@@ -0,0 +1,88 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Streams of events.
+const (
+	// StreamStatus events contain the aternos.ServerInfo of each status message.
+	StreamStatus = "status"
+
+	// StreamConsole events contain a console line as string.
+	StreamConsole = "console"
+
+	// StreamHeap events contain an aternos.Heap sample.
+	StreamHeap = "heap"
+
+	// StreamTick events contain an aternos.Tick sample.
+	StreamTick = "tick"
+
+	// StreamQueue events contain a Queue estimate while the server is waiting in queue.
+	StreamQueue = "queue"
+
+	// StreamBackup events contain the aternos.BackupProgress of a backup.
+	StreamBackup = "backup"
+)
+
+// Streams lists all streams.
+var Streams = []string{StreamStatus, StreamConsole, StreamHeap, StreamTick, StreamQueue, StreamBackup}
+
+// Event is a decoded websocket message.
+type Event struct {
+	// Sequence number of the event, increasing by 1 for each event.
+	ID uint64 `json:"id"`
+
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+
+	// Payload of the event, depending on the stream.
+	Data json.RawMessage `json:"data"`
+}
+
+// Queue is the payload of StreamQueue events.
+type Queue struct {
+	Position int `json:"position"`
+	Count    int `json:"count"`
+
+	// Whether it's the server's turn and starting it must be confirmed.
+	Pending bool `json:"pending"`
+
+	// Estimated time left until it's the server's turn, in seconds (see aternos.QueueEstimate.ETA).
+	ETA float64 `json:"eta"`
+}
+
+// ParseStreams parses a comma separated list of streams, e.g. "status,console".
+// An empty string selects all streams.
+func ParseStreams(s string) ([]string, error) {
+	var streams []string
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !isStream(name) {
+			return nil, fmt.Errorf("unknown stream %q", name)
+		}
+		streams = append(streams, name)
+	}
+
+	if len(streams) == 0 {
+		return Streams, nil
+	}
+
+	return streams, nil
+}
+
+func isStream(name string) bool {
+	for _, s := range Streams {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"time"
+)
+
+// Interval at which idle connections are kept alive.
+const keepAliveInterval = 30 * time.Second
+
+// Handler returns an HTTP handler that serves server-sent events on /events and websockets on /ws.
+// Both accept a comma separated list of streams in the streams query parameter, see ParseStreams.
+func (r *Relay) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", r.ServeEvents)
+	mux.HandleFunc("/ws", r.ServeWebsocket)
+	return mux
+}
+
+// subscribe subscribes to the streams of the streams query parameter.
+func (r *Relay) subscribe(w http.ResponseWriter, req *http.Request) (*Subscription, bool) {
+	streams, err := ParseStreams(req.URL.Query().Get("streams"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return r.Subscribe(streams...), true
+}
+
+// ServeEvents streams events as server-sent events.
+// The event type is the stream and the data is the Event as JSON.
+func (r *Relay) ServeEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	s, ok := r.subscribe(w, req)
+	if !ok {
+		return
+	}
+	defer s.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-s.Events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Stream, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+var upgrader = websocket.Upgrader{}
+
+// ServeWebsocket streams events as JSON text messages over a websocket connection.
+// Messages that are received from the client are ignored.
+func (r *Relay) ServeWebsocket(w http.ResponseWriter, req *http.Request) {
+	s, ok := r.subscribe(w, req)
+	if !ok {
+		return
+	}
+	defer s.Close()
+
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Read until the client disconnects, which also handles control messages.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		case event, ok := <-s.Events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"), time.Now().Add(time.Second))
+				return
+			}
+			if err = conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Package relay shares a single websocket connection to Aternos with many local subscribers.
+//
+// Aternos only comfortably allows a single websocket connection per account.
+// A Relay owns that connection, decodes the messages it receives into events
+// and broadcasts them over server-sent events and websockets.
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	aternos "github.com/sleeyax/aternos-api"
+	"sync"
+	"time"
+)
+
+// Relay broadcasts the messages of a websocket connection to subscribers.
+type Relay struct {
+	// Amount of most recent console lines that are replayed to new subscribers of StreamConsole.
+	ReplayLines int
+
+	// Amount of events that can be buffered per subscriber.
+	// Subscribers that fall further behind are disconnected.
+	SubscriberBuffer int
+
+	// Time to wait before reconnecting to the websocket server.
+	ReconnectDelay time.Duration
+
+	// Called when the websocket connection fails, or when the current status can't be fetched after connecting.
+	OnError func(err error)
+
+	api   *aternos.Api
+	queue *aternos.QueueTracker
+
+	// serverInfo fetches the current server info.
+	serverInfo func() (aternos.ServerInfo, error)
+
+	mu          sync.Mutex
+	nextId      uint64
+	subscribers map[*Subscription]struct{}
+	status      *Event
+	console     []Event
+}
+
+// New allocates a new Relay with default settings for the server of given api.
+func New(api *aternos.Api) *Relay {
+	return &Relay{
+		ReplayLines:      100,
+		SubscriberBuffer: 256,
+		ReconnectDelay:   30 * time.Second,
+		api:              api,
+		queue:            aternos.NewQueueTracker(),
+		serverInfo:       api.GetServerInfo,
+		subscribers:      make(map[*Subscription]struct{}),
+	}
+}
+
+// Run keeps a websocket connection open and broadcasts its messages until ctx is done.
+// The console, heap and tick streams are started when the server status calls for them.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		if err := r.follow(ctx); err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.ReconnectDelay):
+		}
+	}
+}
+
+// follow broadcasts messages of a single websocket connection until it drops or ctx is done.
+func (r *Relay) follow(ctx context.Context) error {
+	wss, err := r.api.ConnectWebSocket()
+	if err != nil {
+		return err
+	}
+	defer wss.Close()
+
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go wss.SendHearthBeats(heartbeatCtx)
+
+	defer wss.AddHandler(r)()
+
+	return r.watch(ctx, wss, wss.Message)
+}
+
+// streams starts the streams of a websocket connection.
+type streams interface {
+	StartConsoleLogStream() error
+	StartHeapInfoStream() error
+	StartTickStream() error
+}
+
+// watch starts the streams that the server status calls for, until messages is closed or ctx is done.
+func (r *Relay) watch(ctx context.Context, wss streams, messages <-chan aternos.WebsocketMessage) error {
+	var status *aternos.ServerStatus
+
+	apply := func(info aternos.ServerInfo) {
+		if status != nil && *status == info.Status {
+			return
+		}
+		status = &info.Status
+
+		switch info.Status {
+		case aternos.Starting, aternos.Loading, aternos.Stopping, aternos.Saving:
+			wss.StartConsoleLogStream()
+		case aternos.Online:
+			wss.StartConsoleLogStream()
+			wss.StartHeapInfoStream()
+			wss.StartTickStream()
+		}
+	}
+
+	// Fetch the current status first, since the websocket server only sends changes.
+	if info, err := r.serverInfo(); err == nil {
+		r.observe(info)
+		apply(info)
+	} else if r.OnError != nil {
+		r.OnError(err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return errors.New("websocket connection closed")
+			}
+
+			if msg.Type != "status" {
+				continue
+			}
+
+			var info aternos.ServerInfo
+			if err := json.Unmarshal(msg.MessageBytes, &info); err == nil {
+				apply(info)
+			}
+		}
+	}
+}
+
+// HandleMessage implements aternos.MessageHandler.
+// It decodes given message and broadcasts the resulting events.
+func (r *Relay) HandleMessage(msg aternos.WebsocketMessage) {
+	switch msg.Type {
+	case "status":
+		var info aternos.ServerInfo
+		if err := json.Unmarshal(msg.MessageBytes, &info); err == nil {
+			r.observe(info)
+		}
+	case "queue_reduced":
+		var reduction aternos.QueueReduction
+		if err := json.Unmarshal(msg.MessageBytes, &reduction); err != nil {
+			return
+		}
+		r.queue.ObserveReduction(reduction)
+		r.publishQueue(false)
+	case "line":
+		if msg.Stream == "console" {
+			r.publish(StreamConsole, msg.Data.Content)
+		}
+	case "heap":
+		var heap aternos.Heap
+		if err := json.Unmarshal(msg.Data.ContentBytes, &heap); err == nil {
+			r.publish(StreamHeap, heap)
+		}
+	case "tick":
+		var tick aternos.Tick
+		if err := json.Unmarshal(msg.Data.ContentBytes, &tick); err == nil {
+			r.publish(StreamTick, tick)
+		}
+	case "backup_progress":
+		var backup aternos.BackupProgress
+		if err := json.Unmarshal(msg.MessageBytes, &backup); err == nil {
+			r.publish(StreamBackup, backup)
+		}
+	}
+}
+
+// observe broadcasts given server info and the queue estimate that follows from it.
+func (r *Relay) observe(info aternos.ServerInfo) {
+	r.publish(StreamStatus, info)
+
+	r.queue.Observe(info)
+	if info.Status == aternos.Preparing {
+		r.publishQueue(info.Queue.Status == aternos.QueuePending)
+	}
+}
+
+func (r *Relay) publishQueue(pending bool) {
+	estimate := r.queue.Estimate()
+	r.publish(StreamQueue, Queue{
+		Position: estimate.Position,
+		Count:    estimate.Count,
+		Pending:  pending,
+		ETA:      estimate.ETA.Seconds(),
+	})
+}
+
+// publish broadcasts an event with given payload.
+func (r *Relay) publish(stream string, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	event := Event{ID: r.nextId, Stream: stream, Time: time.Now(), Data: b}
+
+	switch stream {
+	case StreamStatus:
+		r.status = &event
+	case StreamConsole:
+		r.console = append(r.console, event)
+		if len(r.console) > r.ReplayLines {
+			r.console = append([]Event(nil), r.console[len(r.console)-r.ReplayLines:]...)
+		}
+	}
+
+	for s := range r.subscribers {
+		if !s.accepts(stream) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			// The subscriber can't keep up, so it's disconnected rather than silently missing events.
+			r.unsubscribe(s)
+		}
+	}
+}
+
+// Subscription receives the events of a Relay.
+type Subscription struct {
+	// Events receives the events of the subscribed streams.
+	// It's closed when the subscription is closed, or when the subscriber falls too far behind.
+	Events <-chan Event
+
+	relay   *Relay
+	events  chan Event
+	streams map[string]bool
+}
+
+// Subscribe subscribes to given streams, or to all streams if none are given.
+//
+// The most recent status event and console lines (see ReplayLines) are replayed first,
+// if the corresponding streams are subscribed to.
+// The subscription must be closed when it's no longer used.
+func (r *Relay) Subscribe(streams ...string) *Subscription {
+	if len(streams) == 0 {
+		streams = Streams
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := &Subscription{
+		relay:   r,
+		streams: make(map[string]bool, len(streams)),
+	}
+	for _, stream := range streams {
+		s.streams[stream] = true
+	}
+
+	// Events are replayed in order of their IDs.
+	var replay []Event
+	if r.status != nil && s.accepts(StreamStatus) {
+		replay = append(replay, *r.status)
+	}
+	if s.accepts(StreamConsole) {
+		replay = append(replay, r.console...)
+	}
+	sortEvents(replay)
+
+	s.events = make(chan Event, r.SubscriberBuffer+len(replay))
+	s.Events = s.events
+	for _, event := range replay {
+		s.events <- event
+	}
+
+	r.subscribers[s] = struct{}{}
+
+	return s
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.relay.mu.Lock()
+	defer s.relay.mu.Unlock()
+	s.relay.unsubscribe(s)
+}
+
+func (s *Subscription) accepts(stream string) bool {
+	return s.streams[stream]
+}
+
+// unsubscribe removes given subscription.
+// The caller must hold r.mu.
+func (r *Relay) unsubscribe(s *Subscription) {
+	if _, ok := r.subscribers[s]; ok {
+		delete(r.subscribers, s)
+		close(s.events)
+	}
+}
+
+// sortEvents sorts events by ID.
+// The status event is the only event that may be out of order, so insertion sort suffices.
+func sortEvents(events []Event) {
+	for i := 1; i < len(events); i++ {
+		for j := i; j > 0 && events[j].ID < events[j-1].ID; j-- {
+			events[j], events[j-1] = events[j-1], events[j]
+		}
+	}
+}
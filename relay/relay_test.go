@@ -0,0 +1,270 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	aternos "github.com/sleeyax/aternos-api"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestRelay() *Relay {
+	return New(aternos.New(&aternos.Options{}))
+}
+
+func statusMessage(info aternos.ServerInfo) aternos.WebsocketMessage {
+	b, _ := json.Marshal(info)
+	return aternos.WebsocketMessage{Stream: "status", Type: "status", Message: string(b), MessageBytes: b}
+}
+
+func lineMessage(line string) aternos.WebsocketMessage {
+	return aternos.WebsocketMessage{Stream: "console", Type: "line", Data: aternos.Data{Content: line, ContentBytes: []byte(line)}}
+}
+
+func receive(t *testing.T, s *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-s.Events:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+// fakeStreams records the streams that are started.
+type fakeStreams struct {
+	mu      sync.Mutex
+	started []string
+}
+
+func (f *fakeStreams) start(stream string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = append(f.started, stream)
+	return nil
+}
+
+func (f *fakeStreams) StartConsoleLogStream() error { return f.start("console") }
+func (f *fakeStreams) StartHeapInfoStream() error   { return f.start("heap") }
+func (f *fakeStreams) StartTickStream() error       { return f.start("tick") }
+
+func TestRelay_watch_online(t *testing.T) {
+	r := newTestRelay()
+	r.serverInfo = func() (aternos.ServerInfo, error) {
+		return aternos.ServerInfo{Status: aternos.Online, Players: 2}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wss := &fakeStreams{}
+	messages := make(chan aternos.WebsocketMessage, 1)
+	done := make(chan error, 1)
+	go func() { done <- r.watch(ctx, wss, messages) }()
+
+	// The server is already online, so no status message is sent, but the status is replayed.
+	s := r.Subscribe(StreamStatus)
+	defer s.Close()
+	if event := receive(t, s); !strings.Contains(string(event.Data), `"status":"online"`) {
+		t.Fatalf("expected online status, got %s", event.Data)
+	}
+
+	// Receiving the same status again doesn't restart the streams.
+	messages <- statusMessage(aternos.ServerInfo{Status: aternos.Online})
+	close(messages)
+	if err := <-done; err == nil {
+		t.Fatal("expected closed connection error")
+	}
+
+	if started := strings.Join(wss.started, ","); started != "console,heap,tick" {
+		t.Fatalf("expected console, heap and tick streams to be started once, got %s", started)
+	}
+}
+
+func TestRelay_HandleMessage(t *testing.T) {
+	r := newTestRelay()
+	s := r.Subscribe()
+	defer s.Close()
+
+	heap := aternos.Data{Content: `{"usage":1024}`, ContentBytes: []byte(`{"usage":1024}`)}
+	backup, _ := json.Marshal(aternos.BackupProgress{Id: "1", Progress: 50})
+
+	info := aternos.ServerInfo{Status: aternos.Preparing}
+	info.Queue = aternos.Queue{Position: 2, Count: 5, Status: aternos.QueuePending}
+
+	r.HandleMessage(statusMessage(info))
+	r.HandleMessage(lineMessage("hello"))
+	r.HandleMessage(aternos.WebsocketMessage{Stream: "heap", Type: "heap", Data: heap})
+	r.HandleMessage(aternos.WebsocketMessage{Type: "backup_progress", MessageBytes: backup})
+	r.HandleMessage(aternos.WebsocketMessage{Type: "ready"})
+
+	expected := []struct {
+		stream string
+		data   string
+	}{
+		{StreamStatus, ""},
+		{StreamQueue, `{"position":2,"count":5,"pending":true,"eta":0}`},
+		{StreamConsole, `"hello"`},
+		{StreamHeap, `{"usage":1024}`},
+		{StreamBackup, `{"id":"1","progress":50,"action":"","auto":false,"done":false}`},
+	}
+
+	for i, e := range expected {
+		event := receive(t, s)
+		if event.ID != uint64(i+1) || event.Stream != e.stream {
+			t.Fatalf("expected event %d on %s, got %d on %s", i+1, e.stream, event.ID, event.Stream)
+		}
+		if e.data != "" && string(event.Data) != e.data {
+			t.Errorf("%s: expected data %s, got %s", e.stream, e.data, event.Data)
+		}
+	}
+
+	select {
+	case event := <-s.Events:
+		t.Fatalf("unexpected event %+v", event)
+	default:
+	}
+}
+
+func TestRelay_Subscribe_replay(t *testing.T) {
+	r := newTestRelay()
+	r.ReplayLines = 2
+
+	r.HandleMessage(lineMessage("one"))
+	r.HandleMessage(statusMessage(aternos.ServerInfo{Status: aternos.Starting}))
+	r.HandleMessage(lineMessage("two"))
+	r.HandleMessage(lineMessage("three"))
+
+	s := r.Subscribe(StreamStatus, StreamConsole)
+	defer s.Close()
+
+	for _, e := range []struct {
+		id     uint64
+		stream string
+	}{{2, StreamStatus}, {3, StreamConsole}, {4, StreamConsole}} {
+		if event := receive(t, s); event.ID != e.id || event.Stream != e.stream {
+			t.Fatalf("expected replay of event %d on %s, got %d on %s", e.id, e.stream, event.ID, event.Stream)
+		}
+	}
+
+	// Only subscribed streams are received.
+	filtered := r.Subscribe(StreamHeap)
+	defer filtered.Close()
+	r.HandleMessage(lineMessage("four"))
+	r.HandleMessage(aternos.WebsocketMessage{Type: "tick", Data: aternos.Data{Content: `{"averageTickTime":5}`, ContentBytes: []byte(`{"averageTickTime":5}`)}})
+	select {
+	case event := <-filtered.Events:
+		t.Fatalf("unexpected event %+v", event)
+	default:
+	}
+}
+
+func TestRelay_slowSubscriber(t *testing.T) {
+	r := newTestRelay()
+	r.SubscriberBuffer = 1
+
+	s := r.Subscribe(StreamConsole)
+	r.HandleMessage(lineMessage("one"))
+	r.HandleMessage(lineMessage("two"))
+
+	receive(t, s)
+	if _, ok := <-s.Events; ok {
+		t.Fatal("expected slow subscriber to be disconnected")
+	}
+
+	// Closing a disconnected subscription is harmless.
+	s.Close()
+}
+
+func TestParseStreams(t *testing.T) {
+	if streams, err := ParseStreams(""); err != nil || len(streams) != len(Streams) {
+		t.Fatalf("expected all streams, got %v (%v)", streams, err)
+	}
+	if streams, err := ParseStreams("status, console"); err != nil || len(streams) != 2 {
+		t.Fatalf("expected 2 streams, got %v (%v)", streams, err)
+	}
+	if _, err := ParseStreams("status,nope"); err == nil {
+		t.Fatal("expected unknown stream to be rejected")
+	}
+}
+
+func TestRelay_ServeEvents(t *testing.T) {
+	r := newTestRelay()
+	r.HandleMessage(lineMessage("hello"))
+
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+
+	if res, err := http.Get(srv.URL + "/events?streams=nope"); err != nil || res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+
+	res, err := http.Get(srv.URL + "/events?streams=console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	reader := bufio.NewReader(res.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	if lines[0] != "id: 1" || lines[1] != "event: console" || !strings.Contains(lines[2], `"data":"hello"`) {
+		t.Fatalf("unexpected event %q", lines)
+	}
+}
+
+func TestRelay_ServeWebsocket(t *testing.T) {
+	r := newTestRelay()
+
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws?streams=console", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Wait until the subscription has been registered.
+	for {
+		r.mu.Lock()
+		n := len(r.subscribers)
+		r.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	r.HandleMessage(lineMessage("hello"))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var event Event
+	if err = conn.ReadJSON(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Stream != StreamConsole || string(event.Data) != `"hello"` {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,59 @@
+package aternos_api
+
+import "sync"
+
+// RequestStats counts the HTTP requests that were sent to an endpoint.
+type RequestStats struct {
+	// Amount of requests sent, including retries.
+	Requests uint64
+
+	// Amount of requests that failed, either because no response was received
+	// or because the response has an error status code (4xx or 5xx).
+	Errors uint64
+
+	// Amount of requests that were retries of a failed request.
+	Retries uint64
+}
+
+// requestStats keeps RequestStats by endpoint.
+type requestStats struct {
+	mu        sync.Mutex
+	endpoints map[string]*RequestStats
+}
+
+// record counts a request to given endpoint.
+func (s *requestStats) record(endpoint string, attempt int, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.endpoints == nil {
+		s.endpoints = make(map[string]*RequestStats)
+	}
+
+	stats, ok := s.endpoints[endpoint]
+	if !ok {
+		stats = &RequestStats{}
+		s.endpoints[endpoint] = stats
+	}
+
+	stats.Requests++
+	if failed {
+		stats.Errors++
+	}
+	if attempt > 1 {
+		stats.Retries++
+	}
+}
+
+// RequestStats returns the amount of HTTP requests that were sent so far by endpoint, e.g. "ajax/server/start".
+func (api *Api) RequestStats() map[string]RequestStats {
+	api.stats.mu.Lock()
+	defer api.stats.mu.Unlock()
+
+	stats := make(map[string]RequestStats, len(api.stats.endpoints))
+	for endpoint, s := range api.stats.endpoints {
+		stats[endpoint] = *s
+	}
+
+	return stats
+}
@@ -0,0 +1,153 @@
+package aternos_api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy describes how requests that failed due to a transient error should be retried.
+type RetryPolicy struct {
+	// Maximum amount of attempts, including the initial request.
+	// A value of 1 or lower disables retrying.
+	MaxAttempts int
+
+	// Delay before the first retry.
+	// The delay doubles after each attempt (exponential backoff).
+	BaseDelay time.Duration
+
+	// Upper bound of the delay between two attempts.
+	// Zero or lower means the delay isn't bounded.
+	MaxDelay time.Duration
+
+	// Fraction of the delay that is randomized, between 0 and 1.
+	// E.g. 0.5 turns a delay of 4s into a random delay between 2s and 4s.
+	Jitter float64
+
+	// Response status codes that are considered transient.
+	StatusCodes []int
+
+	// Retryable reports whether the given transport error is transient.
+	// Defaults to IsTransientError.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy returns the policy that is used when Options.RetryPolicy isn't specified.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.5,
+		StatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+			521, 522, 524, // CloudFlare origin errors
+		},
+	}
+}
+
+// delay returns the amount of time to wait before the next attempt.
+// Attempt is the number of the attempt that just failed, starting at 1.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+
+	return d
+}
+
+// retryableStatus reports whether the given response status code is transient.
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.StatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableError reports whether the given transport error is transient.
+func (p *RetryPolicy) retryableError(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransientError(err)
+}
+
+// IsTransientError reports whether err is a network error that is likely to go away when the request is repeated,
+// such as a connection reset or a timeout.
+//
+// Errors that repeating the request doesn't fix, such as a failed DNS lookup or a refused connection, aren't transient.
+func IsTransientError(err error) bool {
+	if errors.Is(err, UnauthenticatedError) || errors.Is(err, ForbiddenError) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// A failed lookup is reported as temporary when the DNS server failed, which is rarely fixed by the next attempt.
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return false
+	}
+
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// RetryError is returned when a request failed, possibly after being retried.
+type RetryError struct {
+	// Endpoint that was requested.
+	Endpoint string
+
+	// Amount of attempts that were made.
+	Attempts int
+
+	// Error of the last attempt.
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s: failed after %d attempt(s): %s", e.Endpoint, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// isDialError reports whether err occurred while connecting, meaning the request was never sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
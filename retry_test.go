@@ -0,0 +1,136 @@
+package aternos_api
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = time.Millisecond
+	return policy
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	policy := &RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, e := range expected {
+		if d := policy.delay(i + 1); d != e {
+			t.Errorf("attempt %d: expected delay %s, got %s", i+1, e, d)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := policy.delay(3); d < 2*time.Second || d > 4*time.Second {
+			t.Fatalf("jittered delay %s out of bounds", d)
+		}
+	}
+
+	// Without an upper bound the delay keeps doubling.
+	unbounded := &RetryPolicy{BaseDelay: time.Second}
+	if d := unbounded.delay(5); d != 16*time.Second {
+		t.Errorf("expected unbounded delay of 16s, got %s", d)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"eof", io.ErrUnexpectedEOF, true},
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"timeout", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, true},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, false},
+		{"dns", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "aternos.org", IsNotFound: true}}, false},
+		{"dns server", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}}, false},
+		{"tls", &net.OpError{Op: "remote error", Err: errors.New("tls: handshake failure")}, false},
+		{"forbidden", ForbiddenError, false},
+	} {
+		if transient := IsTransientError(test.err); transient != test.transient {
+			t.Errorf("%s: expected transient to be %t, got %t", test.name, test.transient, transient)
+		}
+	}
+}
+
+func TestApi_do_retriesTransientStatus(t *testing.T) {
+	var calls int
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	res, err := api.do(request{path: "server", idempotent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Close()
+
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+
+	stats := api.RequestStats()["server"]
+	if stats != (RequestStats{Requests: 3, Errors: 2, Retries: 2}) {
+		t.Fatalf("unexpected request stats %+v", stats)
+	}
+}
+
+func TestApi_do_exhaustsAttempts(t *testing.T) {
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := api.do(request{path: "server", idempotent: true})
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected RetryError, got %v", err)
+	}
+	if retryErr.Attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", retryErr.Attempts)
+	}
+}
+
+func TestApi_do_nonIdempotent(t *testing.T) {
+	var calls int
+	api := newTestApi(t, &Options{RetryPolicy: testRetryPolicy()}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	// Not retried when there's no way to tell whether the request took effect.
+	_, err := api.do(request{path: "ajax/server/start"})
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 1 || calls != 1 {
+		t.Fatalf("expected a single attempt, got %d call(s) and error %v", calls, err)
+	}
+
+	// Not retried when the request took effect.
+	calls = 0
+	_, err = api.do(request{path: "ajax/server/start", settled: func() (bool, error) { return true, nil }})
+	if !errors.Is(err, errSettled) || calls != 1 {
+		t.Fatalf("expected request to be settled after a single call, got %d call(s) and error %v", calls, err)
+	}
+
+	// Retried when the request didn't take effect.
+	calls = 0
+	_, err = api.do(request{path: "ajax/server/start", settled: func() (bool, error) { return false, nil }})
+	if !errors.As(err, &retryErr) || calls != 3 {
+		t.Fatalf("expected 3 calls, got %d call(s) and error %v", calls, err)
+	}
+}
@@ -0,0 +1,293 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: aternos.proto
+
+package aternospb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AternosClient is the client API for Aternos service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AternosClient interface {
+	// GetServerInfo returns the current server info.
+	GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*ServerInfo, error)
+	// Start starts the server.
+	Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StartResponse, error)
+	// Stop stops the server.
+	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
+	// Confirm confirms the server each time it's its turn in queue,
+	// until it leaves the queue. It shares the confirmer that Start runs, if any;
+	// cancelling the call stops waiting, not confirming.
+	Confirm(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*ConfirmResponse, error)
+	// Events streams the events of the server.
+	// The most recent status and console lines are sent first.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Aternos_EventsClient, error)
+}
+
+type aternosClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAternosClient(cc grpc.ClientConnInterface) AternosClient {
+	return &aternosClient{cc}
+}
+
+func (c *aternosClient) GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*ServerInfo, error) {
+	out := new(ServerInfo)
+	err := c.cc.Invoke(ctx, "/aternos.v1.Aternos/GetServerInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aternosClient) Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StartResponse, error) {
+	out := new(StartResponse)
+	err := c.cc.Invoke(ctx, "/aternos.v1.Aternos/Start", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aternosClient) Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error) {
+	out := new(StopResponse)
+	err := c.cc.Invoke(ctx, "/aternos.v1.Aternos/Stop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aternosClient) Confirm(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*ConfirmResponse, error) {
+	out := new(ConfirmResponse)
+	err := c.cc.Invoke(ctx, "/aternos.v1.Aternos/Confirm", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aternosClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Aternos_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Aternos_ServiceDesc.Streams[0], "/aternos.v1.Aternos/Events", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aternosEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Aternos_EventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type aternosEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *aternosEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AternosServer is the server API for Aternos service.
+// All implementations must embed UnimplementedAternosServer
+// for forward compatibility
+type AternosServer interface {
+	// GetServerInfo returns the current server info.
+	GetServerInfo(context.Context, *GetServerInfoRequest) (*ServerInfo, error)
+	// Start starts the server.
+	Start(context.Context, *StartRequest) (*StartResponse, error)
+	// Stop stops the server.
+	Stop(context.Context, *StopRequest) (*StopResponse, error)
+	// Confirm confirms the server each time it's its turn in queue,
+	// until it leaves the queue. It shares the confirmer that Start runs, if any;
+	// cancelling the call stops waiting, not confirming.
+	Confirm(context.Context, *ConfirmRequest) (*ConfirmResponse, error)
+	// Events streams the events of the server.
+	// The most recent status and console lines are sent first.
+	Events(*EventsRequest, Aternos_EventsServer) error
+	mustEmbedUnimplementedAternosServer()
+}
+
+// UnimplementedAternosServer must be embedded to have forward compatible implementations.
+type UnimplementedAternosServer struct {
+}
+
+func (UnimplementedAternosServer) GetServerInfo(context.Context, *GetServerInfoRequest) (*ServerInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerInfo not implemented")
+}
+func (UnimplementedAternosServer) Start(context.Context, *StartRequest) (*StartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+}
+func (UnimplementedAternosServer) Stop(context.Context, *StopRequest) (*StopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedAternosServer) Confirm(context.Context, *ConfirmRequest) (*ConfirmResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Confirm not implemented")
+}
+func (UnimplementedAternosServer) Events(*EventsRequest, Aternos_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedAternosServer) mustEmbedUnimplementedAternosServer() {}
+
+// UnsafeAternosServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AternosServer will
+// result in compilation errors.
+type UnsafeAternosServer interface {
+	mustEmbedUnimplementedAternosServer()
+}
+
+func RegisterAternosServer(s grpc.ServiceRegistrar, srv AternosServer) {
+	s.RegisterService(&Aternos_ServiceDesc, srv)
+}
+
+func _Aternos_GetServerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServerInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AternosServer).GetServerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/aternos.v1.Aternos/GetServerInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AternosServer).GetServerInfo(ctx, req.(*GetServerInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Aternos_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AternosServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/aternos.v1.Aternos/Start",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AternosServer).Start(ctx, req.(*StartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Aternos_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AternosServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/aternos.v1.Aternos/Stop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AternosServer).Stop(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Aternos_Confirm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AternosServer).Confirm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/aternos.v1.Aternos/Confirm",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AternosServer).Confirm(ctx, req.(*ConfirmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Aternos_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AternosServer).Events(m, &aternosEventsServer{stream})
+}
+
+type Aternos_EventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type aternosEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *aternosEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Aternos_ServiceDesc is the grpc.ServiceDesc for Aternos service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Aternos_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "aternos.v1.Aternos",
+	HandlerType: (*AternosServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetServerInfo",
+			Handler:    _Aternos_GetServerInfo_Handler,
+		},
+		{
+			MethodName: "Start",
+			Handler:    _Aternos_Start_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _Aternos_Stop_Handler,
+		},
+		{
+			MethodName: "Confirm",
+			Handler:    _Aternos_Confirm_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Aternos_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "aternos.proto",
+}
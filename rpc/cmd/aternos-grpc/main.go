@@ -0,0 +1,60 @@
+// Command aternos-grpc serves an Aternos server over gRPC, see the Aternos service in rpc/aternospb/aternos.proto.
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sleeyax/aternos-api/internal/cli"
+	"github.com/sleeyax/aternos-api/relay"
+	"github.com/sleeyax/aternos-api/rpc"
+	"github.com/sleeyax/aternos-api/rpc/aternospb"
+	"google.golang.org/grpc"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	// Parse CLI flags.
+	listen := flag.String("listen", "127.0.0.1:9180", "address to serve gRPC on")
+	creds := cli.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	api, err := creds.NewApi()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := relay.New(api)
+	r.ReconnectDelay = cli.ReconnectDelay
+	r.OnError = cli.LogReconnect
+	go r.Run(ctx)
+
+	s := rpc.NewServer(api, r)
+	defer s.Close()
+
+	srv := grpc.NewServer()
+	aternospb.RegisterAternosServer(srv, s)
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		// Event streams never end by themselves, so they are cancelled rather than awaited.
+		srv.Stop()
+	}()
+
+	log.Printf("Serving gRPC on %s\n", lis.Addr())
+
+	if err = srv.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	aternos "github.com/sleeyax/aternos-api"
+	"github.com/sleeyax/aternos-api/relay"
+	"github.com/sleeyax/aternos-api/rpc/aternospb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func serverInfoToProto(info aternos.ServerInfo) *aternospb.ServerInfo {
+	return &aternospb.ServerInfo{
+		Id:         info.Id,
+		Name:       info.Name,
+		Status:     aternospb.ServerStatus(info.Status),
+		Address:    info.Address,
+		DynIp:      info.DynIP,
+		Port:       int32(info.Port),
+		Software:   info.Software,
+		Version:    info.Version,
+		Bedrock:    info.IsBedrock,
+		Players:    int32(info.Players),
+		MaxPlayers: int32(info.MaxPlayers),
+		PlayerList: info.PlayerList,
+		Queue: &aternospb.Queue{
+			Position: int32(info.Queue.Position),
+			Count:    int32(info.Queue.Count),
+			Pending:  info.Queue.Status == aternos.QueuePending,
+			Minutes:  int32(info.Queue.Minutes),
+		},
+		Ram:  int32(info.RAM),
+		Motd: info.MOTD,
+	}
+}
+
+// eventToProto decodes the payload of a relay event.
+func eventToProto(event relay.Event) (*aternospb.Event, error) {
+	e := &aternospb.Event{Id: event.ID, Time: timestamppb.New(event.Time)}
+
+	var err error
+	switch event.Stream {
+	case relay.StreamStatus:
+		var info aternos.ServerInfo
+		err = json.Unmarshal(event.Data, &info)
+		e.Payload = &aternospb.Event_Status{Status: serverInfoToProto(info)}
+	case relay.StreamConsole:
+		var line string
+		err = json.Unmarshal(event.Data, &line)
+		e.Payload = &aternospb.Event_ConsoleLine{ConsoleLine: line}
+	case relay.StreamHeap:
+		var heap aternos.Heap
+		err = json.Unmarshal(event.Data, &heap)
+		e.Payload = &aternospb.Event_Heap{Heap: &aternospb.Heap{Usage: int64(heap.Usage)}}
+	case relay.StreamTick:
+		var tick aternos.Tick
+		err = json.Unmarshal(event.Data, &tick)
+		e.Payload = &aternospb.Event_Tick{Tick: &aternospb.Tick{AverageTickTime: tick.AverageTickTime}}
+	case relay.StreamQueue:
+		var queue relay.Queue
+		err = json.Unmarshal(event.Data, &queue)
+		e.Payload = &aternospb.Event_Queue{Queue: &aternospb.QueueEstimate{
+			Position: int32(queue.Position),
+			Count:    int32(queue.Count),
+			Pending:  queue.Pending,
+			Eta:      queue.ETA,
+		}}
+	case relay.StreamBackup:
+		var backup aternos.BackupProgress
+		err = json.Unmarshal(event.Data, &backup)
+		e.Payload = &aternospb.Event_Backup{Backup: &aternospb.Backup{
+			Id:       backup.Id,
+			Progress: int32(backup.Progress),
+			Action:   backup.Action,
+			Auto:     backup.Auto,
+			Done:     backup.Done,
+		}}
+	default:
+		return nil, fmt.Errorf("unknown stream %q", event.Stream)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s event: %w", event.Stream, err)
+	}
+
+	return e, nil
+}
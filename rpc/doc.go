@@ -0,0 +1,7 @@
+// Package rpc serves an Aternos server over gRPC, see the Aternos service in aternospb/aternos.proto.
+//
+// Clients only need the generated aternospb package, so they don't embed the scraper and its TLS fingerprinting.
+// The service is served by cmd/aternos-grpc.
+package rpc
+
+//go:generate protoc -I aternospb --go_out=aternospb --go_opt=paths=source_relative --go-grpc_out=aternospb --go-grpc_opt=paths=source_relative aternospb/aternos.proto
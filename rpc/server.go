@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	aternos "github.com/sleeyax/aternos-api"
+	"github.com/sleeyax/aternos-api/relay"
+	"github.com/sleeyax/aternos-api/rpc/aternospb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+)
+
+// controller is the part of aternos.Api that the server controls the Aternos server with.
+type controller interface {
+	GetServerInfo() (aternos.ServerInfo, error)
+	StartServer() error
+	StopServer() error
+}
+
+// Server implements the Aternos service.
+type Server struct {
+	aternospb.UnimplementedAternosServer
+
+	api   controller
+	relay *relay.Relay
+
+	// confirm keeps confirming the server until it leaves the queue.
+	confirm func(ctx context.Context) error
+
+	// Context in which the server is confirmed in the background, cancelled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	confirming *confirmation
+}
+
+// confirmation is a run of confirm in the background.
+type confirmation struct {
+	// Closed when confirm returns.
+	done chan struct{}
+
+	// Error of confirm, set before done is closed.
+	err error
+}
+
+// NewServer allocates a new Server that controls the server of given api.
+// Events are received from given relay, which must be running (see relay.Relay.Run).
+func NewServer(api *aternos.Api, r *relay.Relay) *Server {
+	s := newServer(api, r, nil)
+	s.confirm = func(ctx context.Context) error {
+		confirmer := aternos.NewAutoConfirmer(api)
+
+		// Feed it status updates as they are received, so it doesn't have to wait for polling.
+		sub := r.Subscribe(relay.StreamStatus)
+		defer sub.Close()
+		go func() {
+			for event := range sub.Events {
+				confirmer.HandleMessage(aternos.WebsocketMessage{Type: "status", MessageBytes: event.Data})
+			}
+		}()
+
+		return confirmer.Run(ctx)
+	}
+	return s
+}
+
+func newServer(api controller, r *relay.Relay, confirm func(ctx context.Context) error) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{api: api, relay: r, confirm: confirm, ctx: ctx, cancel: cancel}
+}
+
+// Close stops confirming the server in the background.
+func (s *Server) Close() {
+	s.cancel()
+}
+
+func (s *Server) GetServerInfo(ctx context.Context, req *aternospb.GetServerInfoRequest) (*aternospb.ServerInfo, error) {
+	info, err := s.api.GetServerInfo()
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return serverInfoToProto(info), nil
+}
+
+func (s *Server) Start(ctx context.Context, req *aternospb.StartRequest) (*aternospb.StartResponse, error) {
+	err := s.api.StartServer()
+	if err != nil && !(req.Confirm && errors.Is(err, aternos.ServerInQueueError)) {
+		return nil, statusError(err)
+	}
+
+	if req.Confirm {
+		s.confirmInBackground()
+	}
+
+	return &aternospb.StartResponse{}, nil
+}
+
+// confirmInBackground confirms the server until it leaves the queue, unless that's already happening.
+// It returns the confirmation that's running.
+func (s *Server) confirmInBackground() *confirmation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.confirming != nil {
+		return s.confirming
+	}
+
+	c := &confirmation{done: make(chan struct{})}
+	s.confirming = c
+
+	go func() {
+		c.err = s.confirm(s.ctx)
+
+		s.mu.Lock()
+		s.confirming = nil
+		s.mu.Unlock()
+
+		close(c.done)
+	}()
+
+	return c
+}
+
+func (s *Server) Stop(ctx context.Context, req *aternospb.StopRequest) (*aternospb.StopResponse, error) {
+	if err := s.api.StopServer(); err != nil {
+		return nil, statusError(err)
+	}
+	return &aternospb.StopResponse{}, nil
+}
+
+func (s *Server) Confirm(ctx context.Context, req *aternospb.ConfirmRequest) (*aternospb.ConfirmResponse, error) {
+	info, err := s.api.GetServerInfo()
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if info.Status != aternos.Preparing {
+		return nil, status.Errorf(codes.FailedPrecondition, "server is %s, not waiting in queue", info.Status)
+	}
+
+	// Wait for the confirmer that's already running, if any, rather than confirming twice.
+	c := s.confirmInBackground()
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-c.done:
+	}
+
+	if c.err != nil {
+		if s.ctx.Err() != nil {
+			return nil, status.Error(codes.Canceled, "server is closing")
+		}
+		return nil, statusError(c.err)
+	}
+
+	return &aternospb.ConfirmResponse{}, nil
+}
+
+func (s *Server) Events(req *aternospb.EventsRequest, stream aternospb.Aternos_EventsServer) error {
+	for _, name := range req.Streams {
+		if _, err := relay.ParseStreams(name); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	sub := s.relay.Subscribe(req.Streams...)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell too far behind")
+			}
+
+			e, err := eventToProto(event)
+			if err != nil {
+				continue
+			}
+			if err = stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// statusError converts an error of the Aternos API to a gRPC status error.
+func statusError(err error) error {
+	code := codes.Unavailable
+
+	switch {
+	case errors.Is(err, aternos.ServerAlreadyStartedError),
+		errors.Is(err, aternos.ServerAlreadyStoppedError),
+		errors.Is(err, aternos.ServerInQueueError),
+		errors.Is(err, aternos.EulaNotAcceptedError),
+		errors.Is(err, aternos.WrongVersionError):
+		code = codes.FailedPrecondition
+	case errors.Is(err, aternos.StorageLimitError):
+		code = codes.ResourceExhausted
+	case errors.Is(err, aternos.UnauthenticatedError):
+		code = codes.Unauthenticated
+	case errors.Is(err, aternos.ForbiddenError):
+		code = codes.PermissionDenied
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,192 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	aternos "github.com/sleeyax/aternos-api"
+	"github.com/sleeyax/aternos-api/relay"
+	"github.com/sleeyax/aternos-api/rpc/aternospb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeController struct {
+	info   aternos.ServerInfo
+	starts int32
+}
+
+func (c *fakeController) GetServerInfo() (aternos.ServerInfo, error) {
+	return c.info, nil
+}
+
+func (c *fakeController) StartServer() error {
+	atomic.AddInt32(&c.starts, 1)
+	return nil
+}
+
+func (c *fakeController) StopServer() error {
+	return aternos.ServerAlreadyStoppedError
+}
+
+// newTestClient serves s over an in-memory connection and returns a client for it.
+func newTestClient(t *testing.T, s *Server) aternospb.AternosClient {
+	lis := bufconn.Listen(1 << 20)
+
+	srv := grpc.NewServer()
+	aternospb.RegisterAternosServer(srv, s)
+	go srv.Serve(lis)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Stop()
+		s.Close()
+	})
+
+	return aternospb.NewAternosClient(conn)
+}
+
+func statusMessage(info aternos.ServerInfo) aternos.WebsocketMessage {
+	b, _ := json.Marshal(info)
+	return aternos.WebsocketMessage{Type: "status", MessageBytes: b}
+}
+
+func TestServer_control(t *testing.T) {
+	api := &fakeController{info: aternos.ServerInfo{Name: "test", Status: aternos.Online, DynIP: "1.2.3.4", Port: 25565, PlayerList: []string{"Steve"}}}
+
+	confirmed := make(chan struct{}, 10)
+	s := newServer(api, relay.New(aternos.New(&aternos.Options{})), func(ctx context.Context) error {
+		confirmed <- struct{}{}
+		return nil
+	})
+	client := newTestClient(t, s)
+	ctx := context.Background()
+
+	info, err := client.GetServerInfo(ctx, &aternospb.GetServerInfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != aternospb.ServerStatus_SERVER_STATUS_ONLINE || info.DynIp != "1.2.3.4" || info.Port != 25565 || len(info.PlayerList) != 1 {
+		t.Fatalf("unexpected server info %v", info)
+	}
+
+	if _, err = client.Start(ctx, &aternospb.StartRequest{Confirm: true}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-confirmed:
+	case <-time.After(time.Second):
+		t.Fatal("expected server to be confirmed in the background")
+	}
+
+	if _, err = client.Stop(ctx, &aternospb.StopRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected failed precondition, got %v", err)
+	}
+
+	// Confirming requires the server to be in queue.
+	if _, err = client.Confirm(ctx, &aternospb.ConfirmRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected failed precondition, got %v", err)
+	}
+	api.info.Status = aternos.Preparing
+	if _, err = client.Confirm(ctx, &aternospb.ConfirmRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_Confirm_shared(t *testing.T) {
+	api := &fakeController{info: aternos.ServerInfo{Status: aternos.Preparing}}
+
+	var runs int32
+	release := make(chan struct{})
+	s := newServer(api, relay.New(aternos.New(&aternos.Options{})), func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	})
+	client := newTestClient(t, s)
+	ctx := context.Background()
+
+	if _, err := client.Start(ctx, &aternospb.StartRequest{Confirm: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Confirm(ctx, &aternospb.ConfirmRequest{})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected Confirm to wait for the running confirmer, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Confirm didn't return")
+	}
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("expected 1 confirmer, got %d", n)
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	r := relay.New(aternos.New(&aternos.Options{}))
+	s := newServer(&fakeController{}, r, nil)
+	client := newTestClient(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if stream, err := client.Events(ctx, &aternospb.EventsRequest{Streams: []string{"nope"}}); err == nil {
+		if _, err = stream.Recv(); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument, got %v", err)
+		}
+	}
+
+	// Replayed on connect.
+	r.HandleMessage(statusMessage(aternos.ServerInfo{Status: aternos.Starting}))
+
+	stream, err := client.Events(ctx, &aternospb.EventsRequest{Streams: []string{relay.StreamStatus, relay.StreamConsole}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.GetStatus().GetStatus() != aternospb.ServerStatus_SERVER_STATUS_STARTING {
+		t.Fatalf("expected replayed status event, got %v", event)
+	}
+
+	r.HandleMessage(aternos.WebsocketMessage{Stream: "heap", Type: "heap", Data: aternos.Data{Content: `{"usage":1}`, ContentBytes: []byte(`{"usage":1}`)}})
+	r.HandleMessage(aternos.WebsocketMessage{Stream: "console", Type: "line", Data: aternos.Data{Content: "hello", ContentBytes: []byte("hello")}})
+
+	if event, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if event.GetConsoleLine() != "hello" || event.Id != 3 {
+		t.Fatalf("expected console event 3, got %v", event)
+	}
+}
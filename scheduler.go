@@ -0,0 +1,326 @@
+package aternos_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// ScheduleAction is the action of a ScheduleRule.
+type ScheduleAction string
+
+const (
+	// Start and confirm the server.
+	ScheduleStart ScheduleAction = "start"
+
+	// Stop the server.
+	ScheduleStop ScheduleAction = "stop"
+)
+
+// Runs that are late by less than this amount of time are considered on time.
+const scheduleTolerance = time.Minute
+
+// ScheduleRule performs an action according to a cron expression.
+type ScheduleRule struct {
+	// Name that identifies the rule in the state file.
+	// Defaults to the action followed by the spec, e.g. "start 0 18 * * FRI".
+	Name string
+
+	Action ScheduleAction
+
+	// Cron expression with 5 fields (minute, hour, day of month, month, day of week), e.g. "0 18 * * FRI".
+	// Descriptors such as "@daily" are supported too.
+	Spec string
+
+	// Location in which the spec is interpreted.
+	// Defaults to Scheduler.Location.
+	Location *time.Location
+}
+
+func (r ScheduleRule) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return string(r.Action) + " " + r.Spec
+}
+
+// MissedRunPolicy determines what happens with runs that were missed,
+// e.g. because the scheduler wasn't running at the time.
+type MissedRunPolicy int
+
+const (
+	// Missed runs are skipped.
+	SkipMissed MissedRunPolicy = iota
+
+	// The most recent missed run is performed as soon as possible,
+	// unless it's older than Scheduler.MaxDelay.
+	RunLatestMissed
+)
+
+// ScheduleRun describes a run of a rule.
+type ScheduleRun struct {
+	Rule ScheduleRule
+
+	// Time at which the rule was scheduled to run.
+	Scheduled time.Time
+
+	// Time at which the rule actually ran, or would have.
+	Time time.Time
+
+	// Whether the rule wasn't performed, because the run was missed
+	// or a more recent run of another rule superseded it.
+	Skipped bool
+
+	// Error of the action, if any.
+	Err error
+}
+
+// Scheduler starts and stops the server according to cron expressions.
+//
+// The time each rule last ran can be persisted in a state file,
+// so runs that are missed while the scheduler isn't running are detected when it's restarted.
+type Scheduler struct {
+	Rules []ScheduleRule
+
+	// Location in which the specs of the rules are interpreted.
+	// Defaults to time.Local.
+	Location *time.Location
+
+	// What to do with missed runs.
+	Missed MissedRunPolicy
+
+	// Missed runs older than this are always skipped.
+	MaxDelay time.Duration
+
+	// Optional path of the file to persist the state in.
+	StateFile string
+
+	// Called after each run.
+	// It's called from the goroutine that executes Run.
+	OnRun func(run ScheduleRun)
+
+	api *Api
+	now func() time.Time
+
+	// Cancels confirming the server after it has been started.
+	cancelConfirm context.CancelFunc
+}
+
+// NewScheduler allocates a new Scheduler with default settings.
+func NewScheduler(api *Api, rules ...ScheduleRule) *Scheduler {
+	return &Scheduler{
+		Rules:    rules,
+		MaxDelay: time.Hour,
+		api:      api,
+		now:      time.Now,
+	}
+}
+
+// scheduleState is the content of the state file.
+type scheduleState struct {
+	// Time of the last run of each rule by name.
+	LastRuns map[string]time.Time `json:"lastRuns"`
+}
+
+// Run performs the rules until ctx is done, after which ctx.Err() is returned.
+// It returns an error right away if a spec is invalid or the state file can't be read.
+func (s *Scheduler) Run(ctx context.Context) error {
+	schedules := make([]cron.Schedule, len(s.Rules))
+	for i, rule := range s.Rules {
+		schedule, err := cron.ParseStandard(rule.Spec)
+		if err != nil {
+			return fmt.Errorf("invalid spec of rule %q: %w", rule.name(), err)
+		}
+		schedules[i] = schedule
+	}
+
+	state, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if s.cancelConfirm != nil {
+			s.cancelConfirm()
+		}
+	}()
+
+	// Rules without state start counting from now.
+	now := s.now()
+	for _, rule := range s.Rules {
+		if _, ok := state.LastRuns[rule.name()]; !ok {
+			state.LastRuns[rule.name()] = now
+		}
+	}
+
+	for {
+		now = s.now()
+
+		// Find the most recent due run of each rule, and the rule that runs next.
+		var runs []ScheduleRun
+		latest := -1
+		next := time.Time{}
+
+		for i, rule := range s.Rules {
+			last := state.LastRuns[rule.name()]
+			scheduled := time.Time{}
+			for t := s.next(schedules[i], rule, last); !t.IsZero() && !t.After(now); t = s.next(schedules[i], rule, t) {
+				scheduled = t
+			}
+
+			if scheduled.IsZero() {
+				if t := s.next(schedules[i], rule, last); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+					next = t
+				}
+				continue
+			}
+
+			runs = append(runs, ScheduleRun{Rule: rule, Scheduled: scheduled, Time: now, Skipped: true})
+			if latest < 0 || scheduled.After(runs[latest].Scheduled) {
+				latest = len(runs) - 1
+			}
+		}
+
+		if len(runs) > 0 {
+			// Only the most recent run is performed, since it supersedes the others.
+			run := &runs[latest]
+			late := now.Sub(run.Scheduled)
+			if late < scheduleTolerance || (s.Missed == RunLatestMissed && late <= s.MaxDelay) {
+				run.Skipped = false
+				run.Err = s.perform(ctx, run.Rule.Action)
+			}
+
+			for _, run := range runs {
+				state.LastRuns[run.Rule.name()] = run.Scheduled
+				s.report(run)
+			}
+
+			if err := s.save(state); err != nil {
+				s.api.logger.Error("failed to save schedule state", "error", err)
+			}
+
+			continue
+		}
+
+		var wake <-chan time.Time
+		if !next.IsZero() {
+			wake = time.After(next.Sub(now))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// next returns the time after t at which given rule is scheduled, or zero if it's never scheduled again.
+func (s *Scheduler) next(schedule cron.Schedule, rule ScheduleRule, t time.Time) time.Time {
+	location := rule.Location
+	if location == nil {
+		location = s.Location
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return schedule.Next(t.In(location))
+}
+
+// perform performs given action.
+// Starting the server returns once it has been started, it's confirmed in the background.
+func (s *Scheduler) perform(ctx context.Context, action ScheduleAction) error {
+	if s.cancelConfirm != nil {
+		s.cancelConfirm()
+		s.cancelConfirm = nil
+	}
+
+	switch action {
+	case ScheduleStart:
+		err := s.api.StartServer()
+		if errors.Is(err, ServerAlreadyStartedError) {
+			return nil
+		}
+		// A server that is already waiting in queue still needs to be confirmed.
+		if err != nil && !errors.Is(err, ServerInQueueError) {
+			return err
+		}
+
+		var confirmCtx context.Context
+		confirmCtx, s.cancelConfirm = context.WithCancel(ctx)
+		go func() {
+			if err := NewAutoConfirmer(s.api).Run(confirmCtx); err != nil && confirmCtx.Err() == nil {
+				s.api.logger.Error("failed to confirm scheduled start", "error", err)
+			}
+		}()
+
+		return nil
+	case ScheduleStop:
+		err := s.api.StopServer()
+		if errors.Is(err, ServerAlreadyStoppedError) {
+			return nil
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown schedule action %q", action)
+	}
+}
+
+func (s *Scheduler) report(run ScheduleRun) {
+	switch {
+	case run.Skipped:
+		s.api.logger.Info("scheduled run skipped", "rule", run.Rule.name(), "scheduled", run.Scheduled)
+	case run.Err != nil:
+		s.api.logger.Error("scheduled run failed", "rule", run.Rule.name(), "error", run.Err)
+	default:
+		s.api.logger.Info("scheduled run done", "rule", run.Rule.name())
+	}
+
+	if s.OnRun != nil {
+		s.OnRun(run)
+	}
+}
+
+// load reads the state file, if any.
+func (s *Scheduler) load() (scheduleState, error) {
+	state := scheduleState{LastRuns: make(map[string]time.Time)}
+	if s.StateFile == "" {
+		return state, nil
+	}
+
+	data, err := ioutil.ReadFile(s.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	if err = json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("invalid schedule state file: %w", err)
+	}
+	if state.LastRuns == nil {
+		state.LastRuns = make(map[string]time.Time)
+	}
+
+	return state, nil
+}
+
+// save writes the state file, if any.
+func (s *Scheduler) save(state scheduleState) error {
+	if s.StateFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.StateFile, data)
+}
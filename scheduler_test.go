@@ -0,0 +1,148 @@
+package aternos_api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestScheduler returns a Scheduler of given api with a weekend schedule, of which the start rule last ran
+// on Friday 7 January 2022 and the stop rule on the Saturday after.
+func newTestScheduler(t *testing.T, api *Api, now time.Time) *Scheduler {
+	state, _ := json.Marshal(scheduleState{LastRuns: map[string]time.Time{
+		"start 0 18 * * FRI": time.Date(2022, 1, 7, 18, 0, 0, 0, time.UTC),
+		"stop 0 2 * * SAT":   time.Date(2022, 1, 8, 2, 0, 0, 0, time.UTC),
+	}})
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	if err := ioutil.WriteFile(path, state, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScheduler(api,
+		ScheduleRule{Action: ScheduleStart, Spec: "0 18 * * FRI"},
+		ScheduleRule{Action: ScheduleStop, Spec: "0 2 * * SAT"},
+	)
+	s.Location = time.UTC
+	s.StateFile = path
+	s.now = func() time.Time {
+		return now
+	}
+
+	return s
+}
+
+// runScheduler runs given scheduler until it reported given amount of runs.
+func runScheduler(t *testing.T, s *Scheduler, n int) []ScheduleRun {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reported := make(chan ScheduleRun, n)
+	s.OnRun = func(run ScheduleRun) {
+		reported <- run
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	var runs []ScheduleRun
+	for len(runs) < n {
+		select {
+		case run := <-reported:
+			runs = append(runs, run)
+		case err := <-done:
+			t.Fatalf("scheduler returned early: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for run %d", len(runs)+1)
+		}
+	}
+
+	cancel()
+	<-done
+
+	return runs
+}
+
+func TestScheduler_Run_runLatestMissed(t *testing.T) {
+	// Half an hour after the start rule was scheduled, a week later.
+	f := newFakeAternos(t, `{"status":6}`)
+	s := newTestScheduler(t, f.newApi(), time.Date(2022, 1, 14, 18, 30, 0, 0, time.UTC))
+	s.Missed = RunLatestMissed
+
+	runs := runScheduler(t, s, 1)
+	if runs[0].Skipped || runs[0].Err != nil || runs[0].Rule.Action != ScheduleStart {
+		t.Fatalf("expected start to run, got %+v", runs[0])
+	}
+	if !runs[0].Scheduled.Equal(time.Date(2022, 1, 14, 18, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected scheduled time %s", runs[0].Scheduled)
+	}
+	if n := f.count("start"); n != 1 {
+		t.Fatalf("expected 1 start, got %d", n)
+	}
+
+	// The run must be persisted, so it's not performed again after a restart.
+	state, err := s.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := state.LastRuns["start 0 18 * * FRI"]; !last.Equal(runs[0].Scheduled) {
+		t.Fatalf("expected last run to be persisted, got %s", last)
+	}
+}
+
+func TestScheduler_Run_inQueue(t *testing.T) {
+	// The server is already waiting in queue, so it's only confirmed.
+	f := newFakeAternos(t, `{"status":10}`)
+	s := newTestScheduler(t, f.newApi(), time.Date(2022, 1, 14, 18, 30, 0, 0, time.UTC))
+	s.Missed = RunLatestMissed
+
+	runs := runScheduler(t, s, 1)
+	if runs[0].Skipped || runs[0].Err != nil {
+		t.Fatalf("expected start to succeed, got %+v", runs[0])
+	}
+	if n := f.count("start"); n != 0 {
+		t.Fatalf("expected no starts, got %d", n)
+	}
+}
+
+func TestScheduler_Run_skipMissed(t *testing.T) {
+	f := newFakeAternos(t, `{"status":6}`)
+	s := newTestScheduler(t, f.newApi(), time.Date(2022, 1, 14, 18, 30, 0, 0, time.UTC))
+
+	runs := runScheduler(t, s, 1)
+	if !runs[0].Skipped {
+		t.Fatalf("expected missed run to be skipped, got %+v", runs[0])
+	}
+	if n := f.count("start"); n != 0 {
+		t.Fatalf("expected no starts, got %d", n)
+	}
+}
+
+func TestScheduler_Run_superseded(t *testing.T) {
+	// Both rules are due, but only the most recent one must run.
+	f := newFakeAternos(t, `{"status":6}`)
+	s := newTestScheduler(t, f.newApi(), time.Date(2022, 1, 15, 2, 0, 30, 0, time.UTC))
+
+	runs := runScheduler(t, s, 2)
+	for _, run := range runs {
+		if skipped := run.Rule.Action == ScheduleStart; run.Skipped != skipped {
+			t.Fatalf("unexpected run %+v", run)
+		}
+	}
+	if starts, stops := f.count("start"), f.count("stop"); starts != 0 || stops != 1 {
+		t.Fatalf("expected only a stop, got %d starts and %d stops", starts, stops)
+	}
+}
+
+func TestScheduler_Run_invalidSpec(t *testing.T) {
+	s := NewScheduler(&Api{}, ScheduleRule{Action: ScheduleStart, Spec: "every friday"})
+	if err := s.Run(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -1,13 +1,177 @@
 package aternos_api
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ServerStatus is the numeric status code of a server.
+//
+// A server that's started usually goes through the following statuses:
+// Offline -> Preparing (waiting in queue) -> Loading -> Starting -> Online.
+// When it's stopped: Online -> Stopping -> Saving -> Offline.
+//
+// See ServerStatus.CanTransitionTo for all valid transitions.
 type ServerStatus int
 
 const (
-	Offline   ServerStatus = 0
-	Online    ServerStatus = 1
-	Preparing ServerStatus = 10
-	Starting  ServerStatus = 2
-	Stopping  ServerStatus = 3
-	Saving    ServerStatus = 5
-	Loading   ServerStatus = 6
+	Offline    ServerStatus = 0
+	Online     ServerStatus = 1
+	Preparing  ServerStatus = 10
+	Starting   ServerStatus = 2
+	Stopping   ServerStatus = 3
+	Restarting ServerStatus = 4
+	Saving     ServerStatus = 5
+	Loading    ServerStatus = 6
+	Crashed    ServerStatus = 7
 )
+
+// statusNames maps each known status to its stable name.
+var statusNames = map[ServerStatus]string{
+	Offline:    "offline",
+	Online:     "online",
+	Preparing:  "preparing",
+	Starting:   "starting",
+	Stopping:   "stopping",
+	Restarting: "restarting",
+	Saving:     "saving",
+	Loading:    "loading",
+	Crashed:    "crashed",
+}
+
+// statusTransitions lists the statuses that a server can go to from each known status.
+//
+//	offline    -> preparing, loading, starting
+//	preparing  -> loading, starting, offline (left the queue)
+//	loading    -> starting, offline, crashed
+//	starting   -> online, stopping, offline, crashed
+//	online     -> stopping, restarting, saving, offline, crashed
+//	restarting -> loading, starting, stopping, crashed
+//	stopping   -> saving, offline, crashed
+//	saving     -> offline, online, crashed
+//	crashed    -> offline, saving, preparing, loading, starting
+var statusTransitions = map[ServerStatus][]ServerStatus{
+	Offline:    {Preparing, Loading, Starting},
+	Preparing:  {Loading, Starting, Offline},
+	Loading:    {Starting, Offline, Crashed},
+	Starting:   {Online, Stopping, Offline, Crashed},
+	Online:     {Stopping, Restarting, Saving, Offline, Crashed},
+	Restarting: {Loading, Starting, Stopping, Crashed},
+	Stopping:   {Saving, Offline, Crashed},
+	Saving:     {Offline, Online, Crashed},
+	Crashed:    {Offline, Saving, Preparing, Loading, Starting},
+}
+
+// ParseServerStatus parses a status from either its name (e.g. "online") or its numeric code (e.g. "1").
+func ParseServerStatus(s string) (ServerStatus, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+
+	for status, n := range statusNames {
+		if n == name {
+			return status, nil
+		}
+	}
+
+	if code, err := strconv.Atoi(name); err == nil {
+		return ServerStatus(code), nil
+	}
+
+	return 0, fmt.Errorf("invalid server status %q", s)
+}
+
+// String returns the stable name of the status, e.g. "online".
+// Unknown statuses are formatted as "unknown(<code>)".
+func (s ServerStatus) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// IsKnown reports whether the status is one of the statuses defined in this package.
+func (s ServerStatus) IsKnown() bool {
+	_, ok := statusNames[s]
+	return ok
+}
+
+// IsRunning reports whether the server is online and can be joined.
+func (s ServerStatus) IsRunning() bool {
+	return s == Online
+}
+
+// IsStopped reports whether the server isn't running and isn't about to run either.
+func (s ServerStatus) IsStopped() bool {
+	return s == Offline || s == Crashed
+}
+
+// IsTransitional reports whether the server is expected to leave the status on its own shortly,
+// such as while it's starting or stopping.
+func (s ServerStatus) IsTransitional() bool {
+	switch s {
+	case Preparing, Loading, Starting, Restarting, Stopping, Saving:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanTransitionTo reports whether a server can go from status s to the given status directly,
+// according to the transition table above.
+// Staying in the same status is always valid, as is any transition from or to an unknown status.
+func (s ServerStatus) CanTransitionTo(to ServerStatus) bool {
+	if s == to || !s.IsKnown() || !to.IsKnown() {
+		return true
+	}
+
+	for _, status := range statusTransitions[s] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarshalText encodes the status as its stable name, or as its numeric code if it's unknown.
+func (s ServerStatus) MarshalText() ([]byte, error) {
+	if name, ok := statusNames[s]; ok {
+		return []byte(name), nil
+	}
+	return []byte(strconv.Itoa(int(s))), nil
+}
+
+// UnmarshalText decodes a status from either its name or its numeric code.
+func (s *ServerStatus) UnmarshalText(text []byte) error {
+	status, err := ParseServerStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// MarshalJSON encodes the status as its stable name, or as a number if it's unknown.
+func (s ServerStatus) MarshalJSON() ([]byte, error) {
+	if name, ok := statusNames[s]; ok {
+		return json.Marshal(name)
+	}
+	return json.Marshal(int(s))
+}
+
+// UnmarshalJSON decodes a status from either a number, as sent by Aternos, or a string.
+func (s *ServerStatus) UnmarshalJSON(data []byte) error {
+	var code int
+	if err := json.Unmarshal(data, &code); err == nil {
+		*s = ServerStatus(code)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("invalid server status %s", data)
+	}
+
+	return s.UnmarshalText([]byte(name))
+}
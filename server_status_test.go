@@ -0,0 +1,94 @@
+package aternos_api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestServerStatus_String(t *testing.T) {
+	if s := Online.String(); s != "online" {
+		t.Errorf("expected online, got %s", s)
+	}
+	if s := ServerStatus(42).String(); s != "unknown(42)" {
+		t.Errorf("expected unknown(42), got %s", s)
+	}
+}
+
+func TestServerStatus_JSON(t *testing.T) {
+	// Aternos sends numeric codes.
+	var info ServerInfo
+	if err := json.Unmarshal([]byte(`{"status":7}`), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != Crashed {
+		t.Fatalf("expected crashed, got %s", info.Status)
+	}
+
+	tests := map[ServerStatus]string{
+		Preparing:        `"preparing"`,
+		ServerStatus(42): `42`,
+	}
+
+	for status, expected := range tests {
+		data, err := json.Marshal(status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("expected %s, got %s", expected, data)
+		}
+
+		var decoded ServerStatus
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded != status {
+			t.Errorf("expected %s to round trip, got %s", status, decoded)
+		}
+	}
+
+	// Names can be used as map keys.
+	data, err := json.Marshal(map[ServerStatus]int{Online: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"online":1}` {
+		t.Errorf("unexpected map encoding %s", data)
+	}
+
+	var status ServerStatus
+	if err = json.Unmarshal([]byte(`"bogus"`), &status); err == nil {
+		t.Error("expected error for invalid name")
+	}
+}
+
+func TestServerStatus_predicates(t *testing.T) {
+	if !Online.IsRunning() || Starting.IsRunning() {
+		t.Error("unexpected IsRunning result")
+	}
+	if !Preparing.IsTransitional() || Online.IsTransitional() || Offline.IsTransitional() {
+		t.Error("unexpected IsTransitional result")
+	}
+	if !Crashed.IsStopped() || Saving.IsStopped() {
+		t.Error("unexpected IsStopped result")
+	}
+	if ServerStatus(42).IsKnown() || !Loading.IsKnown() {
+		t.Error("unexpected IsKnown result")
+	}
+}
+
+func TestServerStatus_CanTransitionTo(t *testing.T) {
+	lifecycle := []ServerStatus{Offline, Preparing, Loading, Starting, Online, Stopping, Saving, Offline}
+	for i := 1; i < len(lifecycle); i++ {
+		if !lifecycle[i-1].CanTransitionTo(lifecycle[i]) {
+			t.Errorf("expected %s -> %s to be valid", lifecycle[i-1], lifecycle[i])
+		}
+	}
+
+	if Offline.CanTransitionTo(Online) {
+		t.Error("expected offline -> online to be invalid")
+	}
+	if !Online.CanTransitionTo(ServerStatus(42)) {
+		t.Error("expected transitions to unknown statuses to be valid")
+	}
+}
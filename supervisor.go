@@ -0,0 +1,298 @@
+package aternos_api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SupervisorEventType is the type of a SupervisorEvent.
+type SupervisorEventType string
+
+const (
+	// The server stopped and will be restarted after the restart delay.
+	SupervisorStopped SupervisorEventType = "stopped"
+
+	// The server is being restarted.
+	SupervisorRestarting SupervisorEventType = "restarting"
+
+	// The server was started and confirmed.
+	SupervisorRestarted SupervisorEventType = "restarted"
+
+	// Restarting the server failed, it's retried after the restart delay.
+	SupervisorFailed SupervisorEventType = "failed"
+
+	// The server isn't restarted for now, because it's outside the time windows or the restart limit was reached.
+	SupervisorSkipped SupervisorEventType = "skipped"
+)
+
+// SupervisorEvent describes a decision of a Supervisor.
+type SupervisorEvent struct {
+	Type SupervisorEventType
+	Time time.Time
+
+	// Status that triggered the event.
+	Status ServerStatus
+
+	// Why the server is skipped, only set for SupervisorSkipped.
+	Reason string
+
+	// Amount of restarts within the restart period, including the current one.
+	Restarts int
+
+	// Error of a failed restart, only set for SupervisorFailed.
+	Err error
+}
+
+// TimeWindow is a daily period of time.
+type TimeWindow struct {
+	// Time of day at which the window starts, e.g. 8 * time.Hour.
+	Start time.Duration
+
+	// Time of day at which the window ends, e.g. 23 * time.Hour.
+	// If it's before Start, the window ends the next day.
+	End time.Duration
+
+	// Days on which the window starts.
+	// Empty means every day.
+	Days []time.Weekday
+}
+
+// Contains reports whether t falls within the window, in the location of t.
+func (w TimeWindow) Contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End && w.onDay(t.Weekday())
+	}
+
+	// The window spans midnight.
+	if offset >= w.Start {
+		return w.onDay(t.Weekday())
+	}
+	return offset < w.End && w.onDay((t.Weekday()+6)%7)
+}
+
+func (w TimeWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Supervisor keeps the server running by starting and confirming it again whenever it stops.
+type Supervisor struct {
+	// Time windows in which the server is kept running.
+	// Empty means always.
+	Windows []TimeWindow
+
+	// Location of the time windows.
+	// Defaults to time.Local.
+	Location *time.Location
+
+	// Time to wait after the server stopped before it's restarted, and before a failed restart is retried.
+	RestartDelay time.Duration
+
+	// Maximum amount of restarts within RestartPeriod.
+	// Zero means there's no limit.
+	MaxRestarts int
+
+	// Period in which at most MaxRestarts restarts are attempted.
+	RestartPeriod time.Duration
+
+	// Maximum amount of time to wait in queue for a restart.
+	QueueTimeout time.Duration
+
+	// Called with each decision.
+	// It's called from the goroutine that executes Run.
+	OnEvent func(event SupervisorEvent)
+
+	api   *Api
+	watch func(ctx context.Context) <-chan StatusChange
+	now   func() time.Time
+
+	// Times at which restarts were attempted within the restart period.
+	restarts []time.Time
+}
+
+// NewSupervisor allocates a new Supervisor with default settings.
+func NewSupervisor(api *Api) *Supervisor {
+	return &Supervisor{
+		RestartDelay:  time.Minute,
+		MaxRestarts:   5,
+		RestartPeriod: 6 * time.Hour,
+		QueueTimeout:  time.Hour,
+		api:           api,
+		watch:         api.WatchStatus,
+		now:           time.Now,
+	}
+}
+
+// Run keeps the server running until ctx is done.
+// It always returns ctx.Err().
+func (s *Supervisor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Changes are read while the server is being started, keeping only the most recent one,
+	// so that the watcher isn't held up and stale changes aren't acted upon afterwards.
+	changes := s.watch(ctx)
+	updates := make(chan StatusChange, 1)
+	go func() {
+		defer close(updates)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case change, ok := <-changes:
+				if !ok {
+					return
+				}
+				select {
+				case <-updates:
+				default:
+				}
+				updates <- change
+			}
+		}
+	}()
+
+	var (
+		// Most recently observed status.
+		status ServerStatus
+
+		// Fires when the server should be restarted, nil if it shouldn't.
+		restart <-chan time.Time
+
+		// Reason of the most recently emitted skip, to avoid repeating it.
+		skipped string
+	)
+
+	observe := func(change StatusChange) {
+		status = change.To
+		skipped = ""
+		if !status.IsStopped() {
+			restart = nil
+			return
+		}
+		s.emit(SupervisorEvent{Type: SupervisorStopped, Status: status})
+		restart = time.After(s.RestartDelay)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change, ok := <-updates:
+			if !ok {
+				return ctx.Err()
+			}
+			observe(change)
+			continue
+		case <-restart:
+			// A change that was received in the meantime takes precedence.
+			select {
+			case change, ok := <-updates:
+				if !ok {
+					return ctx.Err()
+				}
+				observe(change)
+				continue
+			default:
+			}
+		}
+
+		if reason, retry := s.skip(); reason != "" {
+			if reason != skipped {
+				s.emit(SupervisorEvent{Type: SupervisorSkipped, Status: status, Reason: reason})
+				skipped = reason
+			}
+			restart = time.After(retry)
+			continue
+		}
+		skipped = ""
+
+		s.restarts = append(s.restarts, s.now())
+		s.emit(SupervisorEvent{Type: SupervisorRestarting, Status: status})
+
+		if err := s.start(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.emit(SupervisorEvent{Type: SupervisorFailed, Status: status, Err: err})
+			restart = time.After(s.RestartDelay)
+			continue
+		}
+
+		s.emit(SupervisorEvent{Type: SupervisorRestarted, Status: status})
+		restart = nil
+	}
+}
+
+// skip returns why the server shouldn't be restarted right now, if so, and when to check again.
+func (s *Supervisor) skip() (reason string, retry time.Duration) {
+	now := s.now()
+
+	if s.RestartPeriod > 0 {
+		i := 0
+		for i < len(s.restarts) && now.Sub(s.restarts[i]) >= s.RestartPeriod {
+			i++
+		}
+		s.restarts = s.restarts[i:]
+	}
+
+	if s.MaxRestarts > 0 && len(s.restarts) >= s.MaxRestarts {
+		return "restart limit reached", s.restarts[0].Add(s.RestartPeriod).Sub(now)
+	}
+
+	if len(s.Windows) > 0 {
+		location := s.Location
+		if location == nil {
+			location = time.Local
+		}
+
+		local := now.In(location)
+		for _, window := range s.Windows {
+			if window.Contains(local) {
+				return "", 0
+			}
+		}
+
+		return "outside time windows", time.Minute
+	}
+
+	return "", 0
+}
+
+// start starts the server and confirms it until it has left the queue.
+// A server that is already waiting in queue is only confirmed.
+func (s *Supervisor) start(ctx context.Context) error {
+	if err := s.api.StartServer(); err != nil && !errors.Is(err, ServerAlreadyStartedError) && !errors.Is(err, ServerInQueueError) {
+		return err
+	}
+
+	if s.QueueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.QueueTimeout)
+		defer cancel()
+	}
+
+	return NewAutoConfirmer(s.api).Run(ctx)
+}
+
+func (s *Supervisor) emit(event SupervisorEvent) {
+	event.Time = s.now()
+	event.Restarts = len(s.restarts)
+
+	s.api.logger.Info("supervisor decision", "event", string(event.Type), "status", event.Status, "reason", event.Reason, "restarts", event.Restarts, "error", event.Err)
+
+	if s.OnEvent != nil {
+		s.OnEvent(event)
+	}
+}
@@ -0,0 +1,148 @@
+package aternos_api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeWindow_Contains(t *testing.T) {
+	// Friday 22:00 until Saturday 02:00.
+	window := TimeWindow{Start: 22 * time.Hour, End: 2 * time.Hour, Days: []time.Weekday{time.Friday}}
+
+	tests := []struct {
+		time     time.Time
+		contains bool
+	}{
+		{time.Date(2022, 1, 7, 21, 59, 0, 0, time.UTC), false}, // Friday
+		{time.Date(2022, 1, 7, 22, 0, 0, 0, time.UTC), true},
+		{time.Date(2022, 1, 8, 1, 59, 0, 0, time.UTC), true}, // Saturday
+		{time.Date(2022, 1, 8, 2, 0, 0, 0, time.UTC), false},
+		{time.Date(2022, 1, 8, 23, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+		if contains := window.Contains(test.time); contains != test.contains {
+			t.Errorf("%s: expected %v, got %v", test.time, test.contains, contains)
+		}
+	}
+
+	daily := TimeWindow{Start: 8 * time.Hour, End: 20 * time.Hour}
+	if !daily.Contains(time.Date(2022, 1, 9, 12, 0, 0, 0, time.UTC)) || daily.Contains(time.Date(2022, 1, 9, 20, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected result of daily window")
+	}
+}
+
+// newTestSupervisor returns a Supervisor of given api that observes the status changes sent to changes
+// and reports its events to events.
+func newTestSupervisor(api *Api, changes <-chan StatusChange, events chan<- SupervisorEvent) *Supervisor {
+	s := NewSupervisor(api)
+	s.RestartDelay = time.Millisecond
+	s.watch = func(ctx context.Context) <-chan StatusChange {
+		return changes
+	}
+	s.OnEvent = func(event SupervisorEvent) {
+		events <- event
+	}
+	return s
+}
+
+func receiveEvent(t *testing.T, events <-chan SupervisorEvent, want SupervisorEventType) SupervisorEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		if event.Type != want {
+			t.Fatalf("expected %s event, got %+v", want, event)
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s event", want)
+		return SupervisorEvent{}
+	}
+}
+
+func TestSupervisor_Run(t *testing.T) {
+	// The server goes straight to loading once it's started.
+	f := newFakeAternos(t, `{"status":6}`)
+	changes, events := make(chan StatusChange), make(chan SupervisorEvent, 10)
+	s := newTestSupervisor(f.newApi(), changes, events)
+	s.MaxRestarts = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	changes <- StatusChange{From: Online, To: Offline}
+	receiveEvent(t, events, SupervisorStopped)
+	receiveEvent(t, events, SupervisorRestarting)
+	receiveEvent(t, events, SupervisorRestarted)
+
+	changes <- StatusChange{From: Offline, To: Online}
+
+	// The restart limit has been reached now.
+	changes <- StatusChange{From: Online, To: Crashed}
+	receiveEvent(t, events, SupervisorStopped)
+	if event := receiveEvent(t, events, SupervisorSkipped); event.Reason != "restart limit reached" {
+		t.Fatalf("unexpected reason %q", event.Reason)
+	}
+
+	if n := f.count("start"); n != 1 {
+		t.Fatalf("expected 1 start, got %d", n)
+	}
+}
+
+func TestSupervisor_Run_changesWhileStarting(t *testing.T) {
+	// The server doesn't enter the queue, so it's being started until the queue timeout.
+	f := newFakeAternos(t, `{"status":0}`)
+	changes, events := make(chan StatusChange), make(chan SupervisorEvent, 10)
+	s := newTestSupervisor(f.newApi(), changes, events)
+	s.QueueTimeout = time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	changes <- StatusChange{From: Online, To: Offline}
+	receiveEvent(t, events, SupervisorStopped)
+	receiveEvent(t, events, SupervisorRestarting)
+
+	// Changes are received while the server is being started.
+	for _, change := range []StatusChange{{From: Offline, To: Starting}, {From: Starting, To: Online}} {
+		select {
+		case changes <- change:
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("change to %s wasn't received while starting", change.To)
+		}
+	}
+	receiveEvent(t, events, SupervisorFailed)
+
+	// The server turned out to be online in the meantime, so it isn't restarted again.
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if n := f.count("start"); n != 1 {
+		t.Fatalf("expected 1 start, got %d", n)
+	}
+}
+
+func TestSupervisor_Run_outsideWindow(t *testing.T) {
+	changes, events := make(chan StatusChange), make(chan SupervisorEvent, 10)
+	s := newTestSupervisor(newFakeAternos(t, `{"status":6}`).newApi(), changes, events)
+	s.Location = time.UTC
+	s.Windows = []TimeWindow{{Start: 8 * time.Hour, End: 20 * time.Hour}}
+	s.now = func() time.Time {
+		return time.Date(2022, 1, 7, 22, 0, 0, 0, time.UTC)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	changes <- StatusChange{From: Offline, To: Offline}
+	receiveEvent(t, events, SupervisorStopped)
+	if event := receiveEvent(t, events, SupervisorSkipped); event.Reason != "outside time windows" {
+		t.Fatalf("unexpected reason %q", event.Reason)
+	}
+}
@@ -0,0 +1,268 @@
+package aternos_api
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dop251/goja"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum amount of time a candidate script may run before it's interrupted.
+var tokenScriptTimeout = 2 * time.Second
+
+const (
+	// Candidate scripts larger than this amount of bytes are skipped.
+	// Goja can't limit memory usage, so this is the best we can do to prevent scripts from exhausting it.
+	tokenScriptMaxSize = 512 * 1024
+
+	// Maximum amount of extracted tokens to cache.
+	tokenCacheSize = 16
+)
+
+// tokenEnvironment is evaluated before each candidate script.
+// It mimics the browser globals that the obfuscated token script may access.
+// Timers are collected and run once the script itself has finished.
+const tokenEnvironment = `
+var window = this;
+var self = window;
+var __timers = [];
+window.setTimeout = function (f) { if (typeof f === 'function') __timers.push(f); return __timers.length; };
+window.setInterval = window.setTimeout;
+window.clearTimeout = function () {};
+window.clearInterval = function () {};
+window.requestAnimationFrame = window.setTimeout;
+window.addEventListener = function () {};
+window.removeEventListener = function () {};
+window.dispatchEvent = function () { return true; };
+var __element = function () {
+	return {
+		style: {}, dataset: {}, classList: {add: function () {}, remove: function () {}, contains: function () { return false; }},
+		setAttribute: function () {}, getAttribute: function () { return null; },
+		appendChild: function (c) { return c; }, removeChild: function (c) { return c; },
+		addEventListener: function () {}, removeEventListener: function () {},
+		querySelector: function () { return null; }, querySelectorAll: function () { return []; },
+		innerHTML: '', textContent: ''
+	};
+};
+window.document = {
+	cookie: '', readyState: 'complete', referrer: '',
+	documentElement: __element(), head: __element(), body: __element(),
+	createElement: __element,
+	getElementById: function () { return null; },
+	getElementsByTagName: function () { return []; },
+	getElementsByClassName: function () { return []; },
+	querySelector: function () { return null; },
+	querySelectorAll: function () { return []; },
+	addEventListener: function () {}, removeEventListener: function () {}
+};
+window.navigator = {userAgent: __userAgent, language: 'en-US', languages: ['en-US', 'en'], platform: 'Win32', webdriver: false, cookieEnabled: true};
+window.location = {href: 'https://aternos.org/server/', protocol: 'https:', host: 'aternos.org', hostname: 'aternos.org', pathname: '/server/', search: '', hash: '', origin: 'https://aternos.org'};
+window.localStorage = window.sessionStorage = {getItem: function () { return null; }, setItem: function () {}, removeItem: function () {}};
+window.screen = {width: 1920, height: 1080, availWidth: 1920, availHeight: 1040, colorDepth: 24};
+window.console = {log: function () {}, warn: function () {}, error: function () {}, info: function () {}, debug: function () {}};
+`
+
+// tokenStrategy selects candidate scripts that may define the ajax token.
+type tokenStrategy struct {
+	name       string
+	candidates func(document *goquery.Document) []string
+}
+
+// tokenStrategies are tried in order until one of them yields a token.
+var tokenStrategies = []tokenStrategy{
+	{
+		// The first inline script that touches the window object, which is how Aternos used to ship the token.
+		name: "window-script",
+		candidates: func(document *goquery.Document) []string {
+			var scripts []string
+			document.Find("script[type='text/javascript']").EachWithBreak(func(i int, selection *goquery.Selection) bool {
+				if script := strings.TrimSpace(selection.Text()); strings.Contains(script, "window") {
+					scripts = append(scripts, script)
+					return false
+				}
+				return true
+			})
+			return scripts
+		},
+	},
+	{
+		// Inline scripts that mention the token by name.
+		name: "token-script",
+		candidates: func(document *goquery.Document) []string {
+			return inlineScripts(document, func(script string) bool {
+				return strings.Contains(script, "AJAX_TOKEN")
+			})
+		},
+	},
+	{
+		// All inline scripts, in case the name of the token is obfuscated.
+		name: "inline-scripts",
+		candidates: func(document *goquery.Document) []string {
+			return inlineScripts(document, func(script string) bool {
+				return !strings.HasPrefix(script, "var lastStatus")
+			})
+		},
+	},
+}
+
+// inlineScripts returns the content of all inline scripts that match given filter.
+func inlineScripts(document *goquery.Document, filter func(script string) bool) []string {
+	var scripts []string
+	document.Find("script:not([src])").Each(func(i int, selection *goquery.Selection) {
+		if script := strings.TrimSpace(selection.Text()); script != "" && filter(script) {
+			scripts = append(scripts, script)
+		}
+	})
+	return scripts
+}
+
+// TokenStrategyError describes why a token extraction strategy failed.
+type TokenStrategyError struct {
+	// Name of the strategy.
+	Strategy string
+
+	// Amount of candidate scripts that were evaluated.
+	Candidates int
+
+	// Error of the last candidate, if any.
+	Err error
+}
+
+func (e *TokenStrategyError) Error() string {
+	if e.Candidates == 0 {
+		return fmt.Sprintf("%s: no candidate scripts found", e.Strategy)
+	}
+	return fmt.Sprintf("%s: %d candidate script(s) failed, last error: %s", e.Strategy, e.Candidates, e.Err)
+}
+
+func (e *TokenStrategyError) Unwrap() error {
+	return e.Err
+}
+
+// TokenExtractionError is returned when the ajax token can't be extracted from a page.
+// This usually means Aternos changed the way the token is obfuscated.
+type TokenExtractionError struct {
+	// Failures of each strategy that was tried, in order.
+	Strategies []*TokenStrategyError
+}
+
+func (e *TokenExtractionError) Error() string {
+	failures := make([]string, len(e.Strategies))
+	for i, s := range e.Strategies {
+		failures[i] = s.Error()
+	}
+	return "failed to extract ajax token: " + strings.Join(failures, "; ")
+}
+
+// tokenCache caches extracted tokens by the hash of the script they were extracted from.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[[sha256.Size]byte]string
+	order  [][sha256.Size]byte
+}
+
+func (c *tokenCache) get(key [sha256.Size]byte) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token, ok := c.tokens[key]
+	return token, ok
+}
+
+func (c *tokenCache) put(key [sha256.Size]byte, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens == nil {
+		c.tokens = make(map[[sha256.Size]byte]string)
+	}
+	if _, ok := c.tokens[key]; ok {
+		return
+	}
+
+	if len(c.order) >= tokenCacheSize {
+		delete(c.tokens, c.order[0])
+		c.order = c.order[1:]
+	}
+
+	c.tokens[key] = token
+	c.order = append(c.order, key)
+}
+
+// extractAjaxToken extracts and unpacks the AJAX TOKEN from given HTML document.
+func (api *Api) extractAjaxToken(document *goquery.Document) (string, error) {
+	extractionErr := &TokenExtractionError{}
+
+	for _, strategy := range tokenStrategies {
+		candidates := strategy.candidates(document)
+		strategyErr := &TokenStrategyError{Strategy: strategy.name, Candidates: len(candidates)}
+
+		for _, script := range candidates {
+			token, err := api.evalTokenScript(script)
+			if err == nil {
+				return token, nil
+			}
+			strategyErr.Err = err
+		}
+
+		api.logger.Debug("token extraction strategy failed", "strategy", strategy.name, "error", strategyErr)
+		extractionErr.Strategies = append(extractionErr.Strategies, strategyErr)
+	}
+
+	return "", extractionErr
+}
+
+// evalTokenScript evaluates given script in a sandbox and returns the token it defines.
+func (api *Api) evalTokenScript(script string) (string, error) {
+	if len(script) > tokenScriptMaxSize {
+		return "", fmt.Errorf("script too large (%d bytes)", len(script))
+	}
+
+	key := sha256.Sum256([]byte(script))
+	if token, ok := api.tokens.get(key); ok {
+		return token, nil
+	}
+
+	vm := goja.New()
+
+	timer := time.AfterFunc(tokenScriptTimeout, func() {
+		vm.Interrupt("timeout")
+	})
+	defer timer.Stop()
+
+	if err := vm.Set("atob", atob); err != nil {
+		return "", err
+	}
+	if err := vm.Set("__userAgent", api.client.Options.Headers.Get("User-Agent")); err != nil {
+		return "", err
+	}
+	if _, err := vm.RunString(tokenEnvironment); err != nil {
+		return "", err
+	}
+
+	if _, err := vm.RunString(script); err != nil {
+		return "", err
+	}
+
+	// Run the timers that were scheduled by the script, in case the token is set asynchronously.
+	if _, err := vm.RunString("for (var i = 0; i < __timers.length && i < 100; i++) { try { __timers[i](); } catch (e) {} }"); err != nil {
+		return "", err
+	}
+
+	v := vm.Get("AJAX_TOKEN")
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return "", errors.New("script didn't define AJAX_TOKEN")
+	}
+
+	token := v.String()
+	if token == "" {
+		return "", errors.New("script defined an empty AJAX_TOKEN")
+	}
+
+	api.tokens.put(key, token)
+
+	return token, nil
+}
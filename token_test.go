@@ -0,0 +1,101 @@
+package aternos_api
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestDocument(t *testing.T, scripts ...string) *goquery.Document {
+	t.Helper()
+
+	var html strings.Builder
+	html.WriteString("<html><head>")
+	for _, script := range scripts {
+		html.WriteString(`<script type="text/javascript">` + script + `</script>`)
+	}
+	html.WriteString("</head><body></body></html>")
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(html.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return document
+}
+
+func TestApi_extractAjaxToken(t *testing.T) {
+	tests := map[string][]string{
+		"legacy": {
+			`var lastStatus = {};`,
+			`(function() { window["AJAX_TOKEN"] = atob("dG9rZW4="); })();`,
+		},
+		"browser globals": {
+			`if (navigator.userAgent.indexOf("Chrome") !== -1 && document.createElement("div").style) { window.AJAX_TOKEN = "token"; }`,
+		},
+		"timer": {
+			`setTimeout(function () { window.AJAX_TOKEN = "token"; }, 100);`,
+		},
+		"obfuscated name": {
+			`console.log("noise");`,
+			`(function (w, k) { w[k] = "token"; })(self, ["AJAX", "TOKEN"].join("_"));`,
+		},
+	}
+
+	for name, scripts := range tests {
+		api := New(&Options{})
+		token, err := api.extractAjaxToken(newTestDocument(t, scripts...))
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if token != "token" {
+			t.Errorf("%s: expected token, got %q", name, token)
+		}
+	}
+}
+
+func TestApi_extractAjaxToken_failure(t *testing.T) {
+	timeout := tokenScriptTimeout
+	tokenScriptTimeout = 50 * time.Millisecond
+	defer func() { tokenScriptTimeout = timeout }()
+
+	api := New(&Options{})
+	_, err := api.extractAjaxToken(newTestDocument(t, `while (window) {}`))
+
+	var extractionErr *TokenExtractionError
+	if !errors.As(err, &extractionErr) {
+		t.Fatalf("expected TokenExtractionError, got %v", err)
+	}
+	if len(extractionErr.Strategies) != len(tokenStrategies) {
+		t.Fatalf("expected all strategies to fail, got %v", extractionErr)
+	}
+
+	windowScript := extractionErr.Strategies[0]
+	if windowScript.Candidates != 1 || !strings.Contains(windowScript.Err.Error(), "timeout") {
+		t.Fatalf("expected window script to time out, got %v", windowScript)
+	}
+	if tokenScript := extractionErr.Strategies[1]; tokenScript.Candidates != 0 {
+		t.Fatalf("expected no token script candidates, got %v", tokenScript)
+	}
+}
+
+func TestApi_extractAjaxToken_cache(t *testing.T) {
+	api := New(&Options{})
+	script := `window.AJAX_TOKEN = "token";`
+
+	if _, err := api.extractAjaxToken(newTestDocument(t, script)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens are cached by their script, so the same page doesn't have to be evaluated twice.
+	if token, ok := api.tokens.get(sha256.Sum256([]byte(script))); !ok || token != "token" {
+		t.Fatalf("expected token to be cached, got %q", token)
+	}
+	if token, err := api.extractAjaxToken(newTestDocument(t, script)); err != nil || token != "token" {
+		t.Fatalf("expected cached token, got %q (%v)", token, err)
+	}
+}
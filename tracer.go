@@ -0,0 +1,442 @@
+package aternos_api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/sleeyax/aternos-api/internal/tlsadapter"
+	"github.com/sleeyax/gotcha"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces the values of cookies and credentials in traces.
+const redacted = "[redacted]"
+
+// truncated is appended to response bodies that exceed Tracer.MaxBodySize, followed by the amount of omitted bytes.
+const truncated = "[truncated %d bytes]"
+
+// redactedParams are query parameters of which the values are redacted in traces.
+// SEC equals the value of the ATERNOS_SEC cookie, TOKEN is the ajax token.
+var redactedParams = []string{"SEC", "TOKEN"}
+
+// Tracer records every HTTP exchange and websocket frame for debugging purposes,
+// such as diagnosing token or CloudFlare failures without an external MITM proxy.
+//
+// Cookie values and the SEC and TOKEN credentials are redacted, so traces can safely be attached to bug reports.
+// A Tracer is safe for concurrent use.
+type Tracer struct {
+	// Maximum amount of HTTP exchanges and websocket frames that are kept.
+	// The oldest ones are discarded first. Zero means there's no limit.
+	MaxExchanges int
+	MaxFrames    int
+
+	// Maximum amount of bytes of a response body that is kept.
+	// Longer bodies are truncated and end with a marker that tells how many bytes were left out.
+	// Zero means there's no limit.
+	MaxBodySize int
+
+	mu        sync.Mutex
+	exchanges []HTTPExchange
+	frames    []WebsocketFrame
+	tls       *TLSInfo
+}
+
+// TLSInfo describes a completed TLS handshake.
+type TLSInfo struct {
+	// Name of the TLS client fingerprint that was used.
+	Fingerprint string `json:"fingerprint"`
+
+	// JA3 fingerprint string of the ClientHello that was sent.
+	JA3 string `json:"ja3"`
+
+	// Negotiated TLS version.
+	// E.g. "TLS 1.3".
+	Version string `json:"version"`
+
+	// Negotiated cipher suite.
+	CipherSuite string `json:"cipherSuite"`
+
+	// Negotiated application protocol (ALPN).
+	NegotiatedProtocol string `json:"alpn"`
+
+	// Server name that was sent (SNI).
+	ServerName string `json:"serverName"`
+}
+
+// HTTPExchange is a recorded HTTP request and its response.
+type HTTPExchange struct {
+	Started  time.Time
+	Duration time.Duration
+
+	Method         string
+	URL            string
+	RequestHeaders http.Header
+
+	// Response status code, or 0 if the request failed.
+	StatusCode      int
+	ResponseHeaders http.Header
+	ResponseBody    []byte
+
+	// Size of the complete response body, which exceeds the length of ResponseBody if it was truncated.
+	ResponseBodySize int
+
+	// Error that occurred, if any.
+	Error string
+
+	// Most recent TLS handshake at the time of the request.
+	TLS *TLSInfo
+}
+
+// WebsocketFrame is a recorded websocket message.
+type WebsocketFrame struct {
+	Time time.Time `json:"time"`
+
+	// Either "send" or "receive".
+	Direction string `json:"direction"`
+
+	// Websocket message type.
+	// E.g. websocket.TextMessage.
+	Type int `json:"type"`
+
+	Data string `json:"data"`
+}
+
+// NewTracer allocates a new Tracer with default limits.
+func NewTracer() *Tracer {
+	return &Tracer{
+		MaxExchanges: 1000,
+		MaxFrames:    10000,
+		MaxBodySize:  256 << 10,
+	}
+}
+
+// Exchanges returns all recorded HTTP exchanges.
+func (t *Tracer) Exchanges() []HTTPExchange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]HTTPExchange(nil), t.exchanges...)
+}
+
+// Frames returns all recorded websocket frames.
+func (t *Tracer) Frames() []WebsocketFrame {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]WebsocketFrame(nil), t.frames...)
+}
+
+// Reset discards all recorded data.
+func (t *Tracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchanges = nil
+	t.frames = nil
+}
+
+// traceHandshake records a TLS handshake.
+func (t *Tracer) traceHandshake(info tlsadapter.HandshakeInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tls = &TLSInfo{
+		Fingerprint:        info.Fingerprint,
+		JA3:                info.JA3,
+		Version:            tlsVersionName(info.Version),
+		CipherSuite:        tls.CipherSuiteName(info.CipherSuite),
+		NegotiatedProtocol: info.NegotiatedProtocol,
+		ServerName:         info.ServerName,
+	}
+}
+
+// traceHTTP records the result of a request sent with given client options.
+// The response body is read and replaced, so it can still be consumed afterwards.
+func (t *Tracer) traceHTTP(options *gotcha.Options, started time.Time, res *gotcha.Response, err error) {
+	var response *http.Response
+	if res != nil {
+		response = res.Response
+	}
+	t.trace(options.Method, options.FullUrl, options.Headers, options.CookieJar, started, response, err)
+}
+
+// traceWebsocket records the result of a websocket handshake with given url and request headers.
+func (t *Tracer) traceWebsocket(u *url.URL, headers http.Header, jar http.CookieJar, started time.Time, res *http.Response, err error) {
+	t.trace(http.MethodGet, u, headers, jar, started, res, err)
+}
+
+// trace records an HTTP exchange.
+// The response body is read and replaced, so it can still be consumed afterwards.
+func (t *Tracer) trace(method string, u *url.URL, headers http.Header, jar http.CookieJar, started time.Time, res *http.Response, err error) {
+	exchange := HTTPExchange{
+		Started:        started,
+		Duration:       time.Since(started),
+		Method:         method,
+		URL:            redactURL(u),
+		RequestHeaders: redactHeaders(headers),
+	}
+
+	if jar != nil {
+		var cookies []string
+		for _, cookie := range jar.Cookies(u) {
+			cookies = append(cookies, cookie.Name+"="+redacted)
+		}
+		if len(cookies) > 0 {
+			exchange.RequestHeaders.Set("Cookie", strings.Join(cookies, "; "))
+		}
+	}
+
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+
+	if res != nil {
+		exchange.StatusCode = res.StatusCode
+		exchange.ResponseHeaders = redactHeaders(res.Header)
+		if res.Body != nil {
+			if body, e := io.ReadAll(res.Body); e == nil {
+				res.Body.Close()
+				res.Body = io.NopCloser(bytes.NewReader(body))
+				exchange.ResponseBody = t.truncate(body)
+				exchange.ResponseBodySize = len(body)
+			}
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	exchange.TLS = t.tls
+	t.exchanges = append(t.exchanges, exchange)
+	if t.MaxExchanges > 0 && len(t.exchanges) > t.MaxExchanges {
+		t.exchanges = t.exchanges[len(t.exchanges)-t.MaxExchanges:]
+	}
+}
+
+// truncate returns a copy of given body of at most MaxBodySize bytes, followed by the truncation marker if it's longer.
+func (t *Tracer) truncate(body []byte) []byte {
+	if t.MaxBodySize <= 0 || len(body) <= t.MaxBodySize {
+		return body
+	}
+
+	truncatedBody := append([]byte(nil), body[:t.MaxBodySize]...)
+	return append(truncatedBody, fmt.Sprintf(truncated, len(body)-t.MaxBodySize)...)
+}
+
+// traceFrame records a websocket frame.
+func (t *Tracer) traceFrame(direction string, messageType int, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.frames = append(t.frames, WebsocketFrame{Time: time.Now(), Direction: direction, Type: messageType, Data: string(data)})
+	if t.MaxFrames > 0 && len(t.frames) > t.MaxFrames {
+		t.frames = t.frames[len(t.frames)-t.MaxFrames:]
+	}
+}
+
+// WriteWebsocketTranscript writes all recorded websocket frames to w as JSON lines.
+func (t *Tracer) WriteWebsocketTranscript(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, frame := range t.Frames() {
+		if err := encoder.Encode(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHAR writes all recorded HTTP exchanges to w in HTTP Archive (HAR) 1.2 format.
+// See http://www.softwareishard.com/blog/har-12-spec/.
+func (t *Tracer) WriteHAR(w io.Writer) error {
+	archive := har{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "aternos-api", Version: "1"},
+		Entries: []harEntry{},
+	}}
+
+	for _, exchange := range t.Exchanges() {
+		archive.Log.Entries = append(archive.Log.Entries, exchange.harEntry())
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+func (e HTTPExchange) harEntry() harEntry {
+	ms := float64(e.Duration) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: e.Started.Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            ms,
+		Request: harRequest{
+			Method:      e.Method,
+			URL:         e.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(e.RequestHeaders),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: harResponse{
+			Status:      e.StatusCode,
+			StatusText:  http.StatusText(e.StatusCode),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(e.ResponseHeaders),
+			Content: harContent{
+				Size:     e.ResponseBodySize,
+				MimeType: e.ResponseHeaders.Get("Content-Type"),
+				Text:     string(e.ResponseBody),
+			},
+			HeadersSize: -1,
+			BodySize:    e.ResponseBodySize,
+		},
+		Cache:   struct{}{},
+		Timings: harTimings{Send: 0, Wait: ms, Receive: 0},
+		TLS:     e.TLS,
+		Error:   e.Error,
+	}
+
+	if u, err := url.Parse(e.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+			}
+		}
+	}
+
+	return entry
+}
+
+// redactURL formats given URL with the values of redactedParams redacted.
+// The order of the query parameters is preserved.
+func redactURL(u *url.URL) string {
+	c := *u
+	params := strings.Split(c.RawQuery, "&")
+	for i, param := range params {
+		name := strings.SplitN(param, "=", 2)[0]
+		for _, p := range redactedParams {
+			if name == p {
+				params[i] = name + "=" + redacted
+			}
+		}
+	}
+	c.RawQuery = strings.Join(params, "&")
+	return c.String()
+}
+
+// redactHeaders returns a copy of given headers with all cookie values redacted.
+func redactHeaders(header http.Header) http.Header {
+	h := header.Clone()
+	if h == nil {
+		return http.Header{}
+	}
+
+	if h.Get("Cookie") != "" {
+		h.Set("Cookie", redacted)
+	}
+
+	if cookies := h.Values("Set-Cookie"); len(cookies) > 0 {
+		h.Del("Set-Cookie")
+		for _, cookie := range cookies {
+			name := strings.SplitN(cookie, "=", 2)[0]
+			h.Add("Set-Cookie", name+"="+redacted)
+		}
+	}
+
+	return h
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return ""
+	}
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	TLS             *TLSInfo    `json:"_tls,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
@@ -0,0 +1,158 @@
+package aternos_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracer_WriteHAR(t *testing.T) {
+	tracer := NewTracer()
+	api := newTestApi(t, &Options{
+		Cookies: []*http.Cookie{{Name: "ATERNOS_SESSION", Value: "secret-session"}},
+		Tracer:  tracer,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "ATERNOS_SESSION", Value: "new-secret-session"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true}`))
+	})
+
+	if err := api.ajax(request{path: "ajax/server/stop", ajax: true, idempotent: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	exchanges := tracer.Exchanges()
+	if len(exchanges) != 1 {
+		t.Fatalf("expected 1 exchange, got %d", len(exchanges))
+	}
+	if string(exchanges[0].ResponseBody) != `{"success":true}` {
+		t.Fatalf("unexpected response body %q", exchanges[0].ResponseBody)
+	}
+
+	var buf bytes.Buffer
+	if err := tracer.WriteHAR(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "secret-session") {
+		t.Fatal("expected cookies to be redacted")
+	}
+
+	var archive har
+	if err := json.Unmarshal(buf.Bytes(), &archive); err != nil {
+		t.Fatal(err)
+	}
+	entry := archive.Log.Entries[0]
+	if entry.Request.Method != "GET" || entry.Response.Status != 200 || entry.Response.Content.MimeType != "application/json" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+
+	// The SEC and TOKEN credentials are redacted.
+	if !strings.HasSuffix(entry.Request.URL, "?SEC=[redacted]&TOKEN=[redacted]") {
+		t.Fatalf("expected credentials to be redacted in %s", entry.Request.URL)
+	}
+	for _, param := range entry.Request.QueryString {
+		if param.Value != redacted {
+			t.Fatalf("expected query parameter %s to be redacted, got %q", param.Name, param.Value)
+		}
+	}
+}
+
+func TestTracer_MaxBodySize(t *testing.T) {
+	tracer := NewTracer()
+	tracer.MaxBodySize = 10
+	api := newTestApi(t, &Options{Tracer: tracer}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 100))
+	})
+
+	res, err := api.do(request{path: "server", idempotent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+
+	// The complete body can still be read.
+	if body, _ := res.Raw(); len(body) != 100 {
+		t.Fatalf("expected body of 100 bytes, got %d", len(body))
+	}
+
+	exchange := tracer.Exchanges()[0]
+	if body := string(exchange.ResponseBody); body != "aaaaaaaaaa[truncated 90 bytes]" {
+		t.Fatalf("unexpected truncated body %q", body)
+	}
+	if exchange.ResponseBodySize != 100 {
+		t.Fatalf("expected body size of 100 bytes, got %d", exchange.ResponseBodySize)
+	}
+}
+
+func TestTracer_traceWebsocket(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("blocked"))
+	}))
+	defer server.Close()
+
+	tracer := NewTracer()
+	u, _ := url.Parse("ws" + strings.TrimPrefix(server.URL, "http") + "/hermes/")
+	headers := http.Header{"Cookie": {"ATERNOS_SESSION=secret-session"}}
+
+	started := time.Now()
+	_, res, err := websocket.DefaultDialer.Dial(u.String(), headers)
+	tracer.traceWebsocket(u, headers, nil, started, res, err)
+
+	// A rejected handshake is recorded along with the response that tells why.
+	exchanges := tracer.Exchanges()
+	if len(exchanges) != 1 {
+		t.Fatalf("expected 1 exchange, got %d", len(exchanges))
+	}
+	exchange := exchanges[0]
+	if exchange.Method != http.MethodGet || exchange.StatusCode != http.StatusForbidden || string(exchange.ResponseBody) != "blocked" || exchange.Error == "" {
+		t.Fatalf("unexpected exchange %+v", exchange)
+	}
+	if exchange.RequestHeaders.Get("Cookie") != redacted {
+		t.Fatalf("expected cookies to be redacted, got %q", exchange.RequestHeaders.Get("Cookie"))
+	}
+}
+
+func TestTracer_limits(t *testing.T) {
+	tracer := NewTracer()
+	tracer.MaxFrames = 2
+
+	for _, data := range []string{"1", "2", "3"} {
+		tracer.traceFrame("receive", 1, []byte(data))
+	}
+
+	frames := tracer.Frames()
+	if len(frames) != 2 || frames[0].Data != "2" || frames[1].Data != "3" {
+		t.Fatalf("expected the 2 most recent frames, got %+v", frames)
+	}
+}
+
+func TestTracer_WriteWebsocketTranscript(t *testing.T) {
+	tracer := NewTracer()
+	tracer.traceFrame("send", 1, []byte(`{"stream":"console","type":"start"}`))
+	tracer.traceFrame("receive", 1, []byte(`{"type":"ready"}`))
+
+	var buf bytes.Buffer
+	if err := tracer.WriteWebsocketTranscript(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	var frame WebsocketFrame
+	if err := json.Unmarshal([]byte(lines[1]), &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Direction != "receive" || frame.Data != `{"type":"ready"}` {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+}
@@ -2,7 +2,10 @@ package aternos_api
 
 import (
 	"encoding/base64"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
 )
 
 // randomString generates a random lowercase string.
@@ -26,3 +29,22 @@ func atob(encoded string) (string, error) {
 	}
 	return string(decoded), nil
 }
+
+// writeFileAtomic replaces the file at given path with data, so it's never left half-written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,206 @@
+package aternos_api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errWebsocketClosed is returned when the websocket server closed the connection.
+var errWebsocketClosed = errors.New("websocket connection closed")
+
+// StatusChange describes a change of the server status.
+type StatusChange struct {
+	// Previous status.
+	From ServerStatus
+
+	// Current status.
+	To ServerStatus
+
+	// Server information at the time of the change.
+	Info ServerInfo
+
+	// Whether the server can't go from From to To directly (see ServerStatus.CanTransitionTo),
+	// which means intermediate statuses were missed.
+	Unexpected bool
+}
+
+// WatchStatus watches the server status and sends each change to the returned channel until ctx is done,
+// after which the channel is closed.
+//
+// The first change describes the current status, with From equal to To.
+//
+// Changes are received through websocket status messages. If the websocket connection can't be established or drops,
+// the status is polled over HTTP instead, more often while the server is starting or stopping.
+// Reconnecting to the websocket server is retried periodically.
+func (api *Api) WatchStatus(ctx context.Context) <-chan StatusChange {
+	w := &statusWatcher{
+		api:               api,
+		connect:           api.ConnectWebSocket,
+		changes:           make(chan StatusChange),
+		pollInterval:      30 * time.Second,
+		fastPollInterval:  5 * time.Second,
+		maxPollInterval:   2 * time.Minute,
+		reconnectInterval: 5 * time.Minute,
+	}
+
+	go w.run(ctx)
+
+	return w.changes
+}
+
+// statusWatcher implements Api.WatchStatus.
+type statusWatcher struct {
+	api     *Api
+	connect func() (*Websocket, error)
+	changes chan StatusChange
+
+	// Poll interval while the server status is stable.
+	pollInterval time.Duration
+
+	// Poll interval while the server status is transitional.
+	fastPollInterval time.Duration
+
+	// Upper bound of the poll interval when polling fails.
+	maxPollInterval time.Duration
+
+	// Time to poll before reconnecting to the websocket server is retried.
+	reconnectInterval time.Duration
+
+	// Most recently observed server info, if any.
+	last *ServerInfo
+}
+
+func (w *statusWatcher) run(ctx context.Context) {
+	defer close(w.changes)
+
+	// Fetch the current status first, so it's known without waiting for the first websocket message.
+	if info, err := w.api.GetServerInfo(); err == nil {
+		if !w.observe(ctx, info) {
+			return
+		}
+	} else {
+		w.api.logger.Warn("failed to get initial server status", "error", err)
+	}
+
+	for ctx.Err() == nil {
+		if err := w.stream(ctx); err != nil {
+			w.api.logger.Warn("websocket unavailable, polling server status", "error", err)
+		}
+		w.poll(ctx, time.Now().Add(w.reconnectInterval))
+	}
+}
+
+// observe sends a change if given info differs from the previous one.
+// It returns false if ctx is done.
+func (w *statusWatcher) observe(ctx context.Context, info ServerInfo) bool {
+	change := StatusChange{From: info.Status, To: info.Status, Info: info}
+
+	if w.last != nil {
+		if w.last.Status == info.Status && w.last.LastChanged == info.LastChanged {
+			return true
+		}
+		change.From = w.last.Status
+		change.Unexpected = !change.From.CanTransitionTo(change.To)
+	}
+
+	if change.Unexpected {
+		w.api.logger.Warn("unexpected server status transition", "from", change.From, "to", change.To)
+	}
+
+	w.last = &info
+
+	select {
+	case w.changes <- change:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// stream observes status messages from the websocket server until the connection drops or ctx is done.
+func (w *statusWatcher) stream(ctx context.Context) error {
+	wss, err := w.connect()
+	if err != nil {
+		return err
+	}
+
+	defer wss.Close()
+
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go wss.SendHearthBeats(heartbeatCtx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-wss.Message:
+			if !ok {
+				return errWebsocketClosed
+			}
+
+			if msg.Type != "status" {
+				break
+			}
+
+			info, err := msg.serverInfo()
+			if err != nil {
+				w.api.logger.Warn("failed to parse status message", "stream", msg.Stream, "error", err)
+				break
+			}
+
+			if !w.observe(ctx, info) {
+				return nil
+			}
+		}
+	}
+}
+
+// poll observes the server status over HTTP until given deadline or until ctx is done.
+func (w *statusWatcher) poll(ctx context.Context, deadline time.Time) {
+	delay := time.Duration(0)
+	failures := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if time.Now().After(deadline) {
+			return
+		}
+
+		info, err := w.api.GetServerInfo()
+		if err != nil {
+			failures++
+			w.api.logger.Warn("failed to poll server status", "error", err)
+		} else {
+			failures = 0
+			if !w.observe(ctx, info) {
+				return
+			}
+		}
+
+		delay = w.pollDelay(failures)
+	}
+}
+
+// pollDelay returns the time to wait before polling again.
+func (w *statusWatcher) pollDelay(failures int) time.Duration {
+	delay := w.pollInterval
+	if w.last != nil && w.last.Status.IsTransitional() {
+		delay = w.fastPollInterval
+	}
+
+	for i := 0; i < failures && delay < w.maxPollInterval; i++ {
+		delay *= 2
+	}
+	if delay > w.maxPollInterval {
+		delay = w.maxPollInterval
+	}
+
+	return delay
+}
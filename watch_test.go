@@ -0,0 +1,141 @@
+package aternos_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestStatusWatcher(api *Api, connect func() (*Websocket, error)) *statusWatcher {
+	return &statusWatcher{
+		api:               api,
+		connect:           connect,
+		changes:           make(chan StatusChange),
+		pollInterval:      time.Millisecond,
+		fastPollInterval:  time.Millisecond,
+		maxPollInterval:   time.Millisecond,
+		reconnectInterval: time.Hour,
+	}
+}
+
+func receiveChange(t *testing.T, changes <-chan StatusChange) StatusChange {
+	t.Helper()
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout while waiting for status change")
+		return StatusChange{}
+	}
+}
+
+func TestApi_WatchStatus_polling(t *testing.T) {
+	// Each status is served twice, to verify identical states are de-duplicated.
+	statuses := []string{
+		`{"status":0,"change":1}`,
+		`{"status":0,"change":1}`,
+		`{"status":2,"change":2}`,
+		`{"status":2,"change":2}`,
+		`{"status":1,"change":3}`,
+	}
+	var polls int32
+
+	api := newTestApi(t, &Options{}, func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&polls, 1)) - 1
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		w.Write([]byte(testServerPage(statuses[i], "token")))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := newTestStatusWatcher(api, func() (*Websocket, error) {
+		return nil, errors.New("websocket unavailable")
+	})
+	go w.run(ctx)
+
+	expected := []StatusChange{{From: Offline, To: Offline}, {From: Offline, To: Starting}, {From: Starting, To: Online}}
+	for _, e := range expected {
+		change := receiveChange(t, w.changes)
+		if change.From != e.From || change.To != e.To || change.Info.Status != e.To {
+			t.Fatalf("expected change %d -> %d, got %d -> %d", e.From, e.To, change.From, change.To)
+		}
+	}
+
+	cancel()
+	for range w.changes {
+	}
+}
+
+func TestApi_WatchStatus_websocket(t *testing.T) {
+	api := newTestApi(t, &Options{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testServerPage(`{"status":0,"change":1}`, "token")))
+	})
+
+	wss := newTestWebsocket(t, func(conn *websocket.Conn) {
+		for _, status := range []string{`{\"status\":0,\"change\":1}`, `{\"status\":10,\"change\":2}`, `{\"status\":2,\"change\":3}`} {
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"type":"status","message":"%s"}`, status)))
+		}
+		echo(conn)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := newTestStatusWatcher(api, func() (*Websocket, error) {
+		return wss, nil
+	})
+	go w.run(ctx)
+
+	expected := []StatusChange{{From: Offline, To: Offline}, {From: Offline, To: Preparing}, {From: Preparing, To: Starting}}
+	for _, e := range expected {
+		if change := receiveChange(t, w.changes); change.From != e.From || change.To != e.To {
+			t.Fatalf("expected change %d -> %d, got %d -> %d", e.From, e.To, change.From, change.To)
+		}
+	}
+
+	cancel()
+	for range w.changes {
+	}
+}
+
+func TestStatusWatcher_pollDelay(t *testing.T) {
+	w := &statusWatcher{pollInterval: 30 * time.Second, fastPollInterval: 5 * time.Second, maxPollInterval: time.Minute}
+
+	if d := w.pollDelay(0); d != 30*time.Second {
+		t.Errorf("expected stable delay, got %s", d)
+	}
+
+	w.last = &ServerInfo{Status: Starting}
+	if d := w.pollDelay(0); d != 5*time.Second {
+		t.Errorf("expected transitional delay, got %s", d)
+	}
+	if d := w.pollDelay(2); d != 20*time.Second {
+		t.Errorf("expected backoff delay, got %s", d)
+	}
+	if d := w.pollDelay(10); d != time.Minute {
+		t.Errorf("expected max delay, got %s", d)
+	}
+}
+
+func TestStatusWatcher_observe_unexpected(t *testing.T) {
+	w := &statusWatcher{api: New(&Options{}), changes: make(chan StatusChange, 2)}
+	ctx := context.Background()
+
+	w.observe(ctx, ServerInfo{Status: Offline, LastChanged: 1})
+	w.observe(ctx, ServerInfo{Status: Online, LastChanged: 2})
+
+	if change := <-w.changes; change.Unexpected {
+		t.Fatal("expected initial status not to be unexpected")
+	}
+	if change := <-w.changes; !change.Unexpected {
+		t.Fatal("expected offline -> online to be unexpected")
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/sleeyax/aternos-api/internal/tlsadapter"
 	httpx "github.com/useflyent/fhttp"
-	"log"
 	"net"
 	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,9 +19,19 @@ const (
 	websocketUrl = "wss://aternos.org/hermes/"
 )
 
+// Websocket is a connection to the Aternos websockets server.
+// It's safe for concurrent use.
 type Websocket struct {
 	// Whether we are connected.
-	isConnected bool
+	// Accessed atomically, 1 means connected.
+	isConnected int32
+
+	// writeMu serializes writes, because the underlying connection supports only one concurrent writer.
+	writeMu sync.Mutex
+
+	// closeOnce ensures the connection is closed only once.
+	closeOnce sync.Once
+	closeErr  error
 
 	// receiverDone indicates whether the receiver goroutine is done processing incoming messages.
 	// It's considered done when the channel is closed.
@@ -30,41 +42,93 @@ type Websocket struct {
 
 	// The current websocket connection.
 	conn *websocket.Conn
+
+	// Logger to write diagnostic messages to.
+	logger Logger
+
+	// Optional tracer to record frames with.
+	tracer *Tracer
+
+	// Rolling windows of heap and tick samples.
+	metrics *metrics
+
+	// Registered message handlers by ID.
+	handlers      map[int]MessageHandler
+	handlersMu    sync.Mutex
+	nextHandlerId int
 }
 
-func (w *Websocket) init() {
-	w.receiverDone = make(chan interface{})
-	w.Message = make(chan WebsocketMessage)
+// newWebsocket wraps given connection and starts receiving messages.
+func newWebsocket(conn *websocket.Conn, logger Logger, tracer *Tracer, metricsWindow time.Duration) *Websocket {
+	w := &Websocket{
+		isConnected:  1,
+		receiverDone: make(chan interface{}),
+		Message:      make(chan WebsocketMessage),
+		conn:         conn,
+		logger:       logger,
+		tracer:       tracer,
+		metrics:      newMetrics(metricsWindow),
+	}
 	go w.startReceiver()
+	return w
 }
 
 // IsConnected returns whether we are connected.
 func (w *Websocket) IsConnected() bool {
-	return w.isConnected
+	return atomic.LoadInt32(&w.isConnected) == 1
 }
 
 // Close closes the websocket connection.
+// Subsequent calls return the result of the first call.
 func (w *Websocket) Close() error {
+	w.closeOnce.Do(func() {
+		w.closeErr = w.close()
+	})
+	return w.closeErr
+}
+
+func (w *Websocket) close() error {
 	// Try to tell the server that we want to close the connection.
-	if err := w.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
-		return fmt.Errorf("failed send close message: %e", err)
+	w.writeMu.Lock()
+	err := w.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	w.writeMu.Unlock()
+	if err != nil {
+		w.conn.Close()
+		return fmt.Errorf("failed send close message: %w", err)
 	}
 
-	w.isConnected = false
+	atomic.StoreInt32(&w.isConnected, 0)
 
 	select {
 	case <-w.receiverDone:
-		log.Println("Receiver Channel Closed! Exiting....")
+		w.logger.Debug("websocket receiver closed")
 		return nil
 	case <-time.After(time.Duration(3) * time.Second):
-		log.Println("Timeout in closing receiving channel. Exiting by force....")
+		w.logger.Warn("timeout while closing websocket receiver, closing connection by force")
 		return w.conn.Close()
 	}
 }
 
 // Send sends a message over the websocket connection.
+//
+// Data is encoded the way it's received: a string is sent as a JSON string and serialized JSON as is.
+// An empty Data is sent as an empty string, e.g. {"stream":"console","type":"start","data":""},
+// rather than as its fields ({"Content":"","ContentBytes":null}) like before Send supported console commands.
 func (w *Websocket) Send(message WebsocketMessage) error {
-	return w.conn.WriteJSON(message)
+	// Encode a pointer, so that Data is encoded with its own MarshalJSON method.
+	data, err := json.Marshal(&message)
+	if err != nil {
+		return err
+	}
+
+	if w.tracer != nil {
+		w.tracer.traceFrame("send", websocket.TextMessage, data)
+	}
+
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	return w.conn.WriteMessage(websocket.TextMessage, data)
 }
 
 // StartConsoleLogStream starts fetching the server start logs (console).
@@ -121,6 +185,16 @@ func (w *Websocket) StopTickStream() error {
 	})
 }
 
+// SendCommand executes a command in the server console, e.g. "say hello".
+// The server must be online.
+func (w *Websocket) SendCommand(command string) error {
+	return w.Send(WebsocketMessage{
+		Stream: "console",
+		Type:   "command",
+		Data:   Data{Content: command, ContentBytes: []byte(command)},
+	})
+}
+
 // SendHeartBeat sends a single keep-alive request.
 // The server doesn't respond to this request, but a heartbeat should be regularly sent to keep the connection alive.
 func (w *Websocket) SendHeartBeat() error {
@@ -160,15 +234,18 @@ func (w *Websocket) startReceiver() {
 
 	for {
 		msgType, rawMsg, err := w.conn.ReadMessage()
+		if err == nil && w.tracer != nil {
+			w.tracer.traceFrame("receive", msgType, rawMsg)
+		}
 
 		if err != nil {
 			closeErr, ok := err.(*websocket.CloseError)
 
 			if !ok || closeErr.Code != websocket.CloseNormalClosure {
-				log.Println("Unknown error in receiver: ", err)
+				w.logger.Error("websocket receiver failed", "error", err)
 			}
 
-			w.isConnected = !ok
+			atomic.StoreInt32(&w.isConnected, 0)
 
 			return
 		}
@@ -177,7 +254,7 @@ func (w *Websocket) startReceiver() {
 		case websocket.TextMessage:
 			var msg WebsocketMessage
 			if err = json.Unmarshal(rawMsg, &msg); err != nil {
-				log.Println("Receiver failed to parse msg: ", err)
+				w.logger.Warn("failed to parse websocket message", "error", err)
 				break
 			}
 
@@ -185,23 +262,33 @@ func (w *Websocket) startReceiver() {
 				msg.MessageBytes = []byte(msg.Message)
 			}
 
+			w.logger.Debug("websocket message received", "stream", msg.Stream, "type", msg.Type)
+
+			w.metrics.observe(msg, time.Now())
+			w.handle(msg)
 			w.Message <- msg
 		case websocket.CloseMessage:
-			w.isConnected = false
+			atomic.StoreInt32(&w.isConnected, 0)
 			return
 		default:
-			log.Printf("Unknown message received: %s\n", rawMsg)
+			w.logger.Debug("unknown websocket message received", "type", msgType, "message", string(rawMsg))
 		}
 	}
 }
 
 // ConnectWebSocket connects to the Aternos websockets server.
 func (api *Api) ConnectWebSocket() (*Websocket, error) {
+	api.clientMu.Lock()
 	headers := api.client.Options.Headers.Clone()
+	origin := api.client.Options.PrefixURL
+	adapter := api.client.Options.Adapter
+	jar := api.client.Options.CookieJar
+	api.clientMu.Unlock()
+
 	headers.Set("accept", "*/*")
 	headers.Set("cache-control", "no-cache")
 	headers.Set("host", "aternos.org")
-	headers.Set("origin", api.client.Options.PrefixURL)
+	headers.Set("origin", origin)
 	headers.Del(httpx.HeaderOrderKey)
 
 	dialer := websocket.Dialer{
@@ -209,19 +296,20 @@ func (api *Api) ConnectWebSocket() (*Websocket, error) {
 		HandshakeTimeout:  30 * time.Second,
 		EnableCompression: true,
 		ProxyTLSConnection: func(ctx context.Context, proxyConn net.Conn) (net.Conn, error) {
-			adapter := (api.client.Options.Adapter).(*tlsadapter.TLSAdapter)
-			return adapter.ConnectTLSContext(ctx, proxyConn)
+			return adapter.(*tlsadapter.TLSAdapter).ConnectTLSContext(ctx, proxyConn)
 		},
-		Jar: api.client.Options.CookieJar,
+		Jar: jar,
 	}
 
-	conn, _, err := dialer.Dial(websocketUrl, headers)
+	started := time.Now()
+	conn, res, err := dialer.Dial(websocketUrl, headers)
+	if tracer := api.Options.Tracer; tracer != nil {
+		u, _ := url.Parse(websocketUrl)
+		tracer.traceWebsocket(u, headers, jar, started, res, err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	wss := &Websocket{conn: conn, isConnected: true}
-	wss.init()
-
-	return wss, nil
+	return newWebsocket(conn, api.logger, api.Options.Tracer, api.Options.MetricsWindow), nil
 }
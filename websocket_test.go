@@ -0,0 +1,185 @@
+package aternos_api
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestWebsocket connects to a local websocket server that handles connections with given handler.
+func newTestWebsocket(t *testing.T, handler func(conn *websocket.Conn)) *Websocket {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		handler(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newWebsocket(conn, nopLogger{}, nil, 0)
+}
+
+// echo sends every received message back until the connection is closed.
+func echo(conn *websocket.Conn) {
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err = conn.WriteMessage(msgType, msg); err != nil {
+			return
+		}
+	}
+}
+
+func TestWebsocket_Send(t *testing.T) {
+	frames := make(chan string, 3)
+	wss := newTestWebsocket(t, func(conn *websocket.Conn) {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			frames <- string(msg)
+		}
+	})
+	defer wss.Close()
+
+	if err := wss.StartConsoleLogStream(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wss.SendCommand("say hi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wss.Send(WebsocketMessage{Type: "test", Data: Data{Content: `{"a":1}`}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`{"stream":"console","type":"start","data":""}`,
+		`{"stream":"console","type":"command","data":"say hi"}`,
+		`{"type":"test","data":{"a":1}}`,
+	} {
+		select {
+		case frame := <-frames:
+			if frame != expected {
+				t.Errorf("expected %s, got %s", expected, frame)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout while waiting for message")
+		}
+	}
+}
+
+func TestWebsocket_concurrentWrites(t *testing.T) {
+	wss := newTestWebsocket(t, echo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go wss.SendHearthBeats(ctx, time.Millisecond)
+
+	const senders, messages = 8, 25
+
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < messages; j++ {
+				if err := wss.StartConsoleLogStream(); err != nil {
+					t.Error(err)
+					return
+				}
+				_ = wss.IsConnected()
+			}
+		}()
+	}
+
+	// Count echoed console messages, ignoring heartbeats.
+	received := make(chan int)
+	go func() {
+		var n int
+		for msg := range wss.Message {
+			if msg.Stream == "console" {
+				n++
+			}
+			if n == senders*messages {
+				break
+			}
+		}
+		received <- n
+	}()
+
+	wg.Wait()
+
+	select {
+	case n := <-received:
+		if n != senders*messages {
+			t.Fatalf("expected %d messages, got %d", senders*messages, n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout while waiting for messages")
+	}
+
+	cancel()
+	go func() {
+		for range wss.Message {
+		}
+	}()
+
+	if err := wss.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wss.Close(); err != nil {
+		t.Fatalf("expected repeated close to return the first result, got %v", err)
+	}
+	if wss.IsConnected() {
+		t.Fatal("expected to be disconnected")
+	}
+}
+
+func TestApi_concurrentRequests(t *testing.T) {
+	api := newTestApi(t, &Options{}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/server":
+			w.Write([]byte(testServerPage(`{"status":1}`, "token")))
+		default:
+			w.Write([]byte(`{"success":true}`))
+		}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := api.GetServerInfo(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := api.StopServer(); err != nil {
+				t.Error(err)
+			}
+			_ = api.GetCookies()
+		}()
+	}
+	wg.Wait()
+}